@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/smlx/hashy/pkg/batch"
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// AuditCmd represents the audit command.
+type AuditCmd struct {
+	Wordlist string `kong:"required,type='existingfile',help='File containing candidate passwords, one per line'"`
+	Hashes   string `kong:"required,type='existingfile',help='File containing password hashes in encoded format, one per line'"`
+	WorkerFlags
+	VerifyFlags
+}
+
+// auditTarget is a password hash prepared with a function which can parse
+// it.
+type auditTarget struct {
+	encodedHash string
+	p           *pwhash.Prepared
+}
+
+// readLines returns the non-empty lines of the given file, without any
+// trailing carriage return.
+func readLines(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSuffix(scanner.Text(), "\r"); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseTargets prepares each of the given encoded hashes once with every
+// matching function. Hashes which can't be prepared, such as those with a cost
+// not permitted by opts, are skipped with a warning.
+func parseTargets(functions map[string]pwhash.Function,
+	encodedHashes []string, opts *pwhash.VerifyOptions) []auditTarget {
+	var targets []auditTarget
+	for _, encodedHash := range encodedHashes {
+		matches := pwhash.Identify(functions, []byte(encodedHash))
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "skipping hash with unknown format: %s\n",
+				encodedHash)
+			continue
+		}
+		for _, f := range matches {
+			p, err := pwhash.Prepare(f, []byte(encodedHash), opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "skipping hash using %s: %v: %s\n", f.ID(),
+					err, encodedHash)
+				continue
+			}
+			targets = append(targets, auditTarget{encodedHash: encodedHash, p: p})
+		}
+	}
+	return targets
+}
+
+// auditMatch is a password in the wordlist which matches a hash.
+type auditMatch struct {
+	function string
+	password string
+}
+
+// audit tests each password in the wordlist against each target, and returns
+// the matching password of each matched hash, keyed by encoded hash. Errors
+// verifying a target are printed to stderr, and the target is not tested
+// further.
+func (cmd *AuditCmd) audit(ctx context.Context, targets []auditTarget,
+	wordlist io.Reader) (map[string]auditMatch, error) {
+	found := map[string]auditMatch{}
+	// failed holds the indexes of targets which returned an error
+	failed := map[int]bool{}
+	var mu sync.Mutex
+	// feed the engine each candidate against each target, so that targets
+	// sharing a salt and cost are hashed once per candidate
+	jobs := make(chan batch.Job)
+	scanner := bufio.NewScanner(wordlist)
+	go func() {
+		defer close(jobs)
+		for scanner.Scan() {
+			candidate := append([]byte(nil),
+				bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))...)
+			for i, t := range targets {
+				// skip hashes which have already been matched, and targets which
+				// returned an error
+				mu.Lock()
+				_, done := found[t.encodedHash]
+				done = done || failed[i]
+				mu.Unlock()
+				if done {
+					continue
+				}
+				select {
+				case jobs <- batch.Job{Hash: t.p, Password: candidate, Tag: i}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	engine := batch.Engine{Workers: cmd.Workers}
+	for r := range engine.Run(ctx, jobs) {
+		t := targets[r.Job.Tag]
+		mu.Lock()
+		switch {
+		case r.Err != nil:
+			// errors after cancellation are reported by the caller
+			if !failed[r.Job.Tag] && ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "error: %s (%s): %v\n", t.encodedHash,
+					t.p.Function.ID(), r.Err)
+			}
+			failed[r.Job.Tag] = true
+		case r.Match:
+			if _, ok := found[t.encodedHash]; !ok {
+				found[t.encodedHash] = auditMatch{
+					function: t.p.Function.ID(),
+					password: string(r.Job.Password),
+				}
+			}
+		}
+		mu.Unlock()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("audit cancelled: %w", err)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read wordlist: %v", err)
+	}
+	return found, nil
+}
+
+// Run the audit command.
+func (cmd *AuditCmd) Run(ctx context.Context,
+	functions map[string]pwhash.Function) error {
+	encodedHashes, err := readLines(cmd.Hashes)
+	if err != nil {
+		return fmt.Errorf("couldn't read hashes: %v", err)
+	}
+	targets := parseTargets(functions, encodedHashes, cmd.options())
+	wordlist, err := os.Open(cmd.Wordlist)
+	if err != nil {
+		return fmt.Errorf("couldn't open wordlist: %v", err)
+	}
+	defer wordlist.Close()
+	found, err := cmd.audit(ctx, targets, wordlist)
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		fmt.Println("No hashes match any password in the wordlist.")
+		return nil
+	}
+	matched := len(found)
+	// report matches in the order of the hashes file
+	fmt.Println("Hashes matching a password in the wordlist:")
+	for _, encodedHash := range encodedHashes {
+		if m, ok := found[encodedHash]; ok {
+			fmt.Printf("* %s (%s): %s\n", encodedHash, m.function, m.password)
+			// report duplicate lines once
+			delete(found, encodedHash)
+		}
+	}
+	return fmt.Errorf("%d hashes match a password in the wordlist", matched)
+}
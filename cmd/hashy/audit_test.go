@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	// md5cryptHash is the md5crypt hash of "hashcat".
+	md5cryptHash = `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`
+	// highCostHash is the sha256crypt hash of "hashcat" with a cost over the
+	// default limit.
+	highCostHash = `$5$rounds=100000$GX7BopJZJxPc/KEK$B0.otSf8vrLEEGD/pM4aaZMj/gpn3R9yyoAXvNAFQY/`
+	// unknownPepperHash is a peppered hash with a pepper ID which isn't
+	// available.
+	unknownPepperHash = `$pepper$missing$$1$28772684$iEwNOgGugqO9.bIz5sk8k/`
+)
+
+// writeLines writes the given content to a new file and returns its name.
+func writeLines(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "lines")
+	if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestParseTargets(t *testing.T) {
+	var testCases = map[string]struct {
+		encodedHashes []string
+		expect        []string
+	}{
+		"valid": {
+			encodedHashes: []string{md5cryptHash},
+			expect:        []string{md5cryptHash},
+		},
+		"unknown format": {
+			encodedHashes: []string{"not a hash", md5cryptHash},
+			expect:        []string{md5cryptHash},
+		},
+		"cost limit": {
+			encodedHashes: []string{highCostHash, md5cryptHash},
+			expect:        []string{md5cryptHash},
+		},
+		"unknown pepper": {
+			encodedHashes: []string{unknownPepperHash, md5cryptHash},
+			expect:        []string{md5cryptHash},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			var parsed []string
+			seen := map[string]bool{}
+			for _, target := range parseTargets(registry(), tc.encodedHashes,
+				(&VerifyFlags{}).options()) {
+				if !seen[target.encodedHash] {
+					seen[target.encodedHash] = true
+					parsed = append(parsed, target.encodedHash)
+				}
+			}
+			sort.Strings(parsed)
+			if strings.Join(parsed, "\n") != strings.Join(tc.expect, "\n") {
+				tt.Fatalf("expected targets %v, got %v", tc.expect, parsed)
+			}
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	var testCases = map[string]struct {
+		hashes        string
+		wordlist      string
+		verifyFlags   VerifyFlags
+		expectMatches int
+	}{
+		"match": {
+			hashes:        md5cryptHash + "\n",
+			wordlist:      "password\nhashcat\n",
+			expectMatches: 1,
+		},
+		"no match": {
+			hashes:   md5cryptHash + "\n",
+			wordlist: "password\nletmein\n",
+		},
+		"CRLF wordlist": {
+			hashes:        md5cryptHash + "\r\n",
+			wordlist:      "password\r\nhashcat\r\n",
+			expectMatches: 1,
+		},
+		"duplicate hash": {
+			hashes:        md5cryptHash + "\n" + md5cryptHash + "\n",
+			wordlist:      "hashcat\n",
+			expectMatches: 1,
+		},
+		"several functions": {
+			// an hmac-md5(s,p) hash, which also identifies as the other salted
+			// md5 and md4 functions
+			hashes:        "313dbfce029b4cdac7bd53496784ad6f:28772684\n",
+			wordlist:      "hashcat\n",
+			expectMatches: 1,
+		},
+		"cost limit skipped": {
+			hashes:   highCostHash + "\n",
+			wordlist: "hashcat\n",
+		},
+		"cost limit raised": {
+			hashes:        highCostHash + "\n" + md5cryptHash + "\n",
+			wordlist:      "hashcat\n",
+			verifyFlags:   VerifyFlags{MaxCost: map[string]uint{"sha256crypt": 100000}},
+			expectMatches: 2,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			cmd := AuditCmd{
+				Wordlist:    writeLines(tt, tc.wordlist),
+				Hashes:      writeLines(tt, tc.hashes),
+				VerifyFlags: tc.verifyFlags,
+			}
+			err := cmd.Run(context.Background(), registry())
+			if tc.expectMatches == 0 {
+				if err != nil {
+					tt.Fatal(err)
+				}
+				return
+			}
+			expect := fmt.Sprintf("%d hashes match a password in the wordlist",
+				tc.expectMatches)
+			if err == nil || err.Error() != expect {
+				tt.Fatalf("expected error %q, got %v", expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// BenchCmd represents the bench command.
+type BenchCmd struct {
+	Target time.Duration `kong:"default='250ms',help='Target duration of a single hash calculation'"`
+}
+
+// Run the bench command.
+func (cmd *BenchCmd) Run(ctx context.Context,
+	functions map[string]pwhash.Function) error {
+	var ids []string
+	for id, f := range functions {
+		if _, ok := f.(pwhash.VariableCost); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FUNCTION\tDEFAULT COST\tRECOMMENDED COST")
+	for _, id := range ids {
+		cost, err := pwhash.Calibrate(ctx, functions[id], cmd.Target)
+		if err != nil {
+			return fmt.Errorf("couldn't calibrate %s: %v", id, err)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\n", id, functions[id].DefaultCost(), cost)
+	}
+	return w.Flush()
+}
@@ -1,49 +1,210 @@
 package main
 
 import (
-	"crypto/subtle"
+	"context"
+	"errors"
 	"fmt"
+	"os"
 
+	"github.com/smlx/hashy/pkg/batch"
 	"github.com/smlx/hashy/pkg/pwhash"
 )
 
 // CheckCmd represents the check command.
 type CheckCmd struct {
-	EncodedHash string `kong:"required,arg,help='Password hash in encoded format'"`
-	Password    string `kong:"required,arg,help='Password to test against hash'"`
+	EncodedHash *string `kong:"optional,arg,help='Password hash in encoded format. Required unless --hashes is given.'"`
+	Password    *string `kong:"optional,arg,help='Password to test against hash. If not given, it is read according to the flags below or prompted for.'"`
+	Hashes      string  `kong:"type='existingfile',help='File containing password hashes in encoded format, one per line, to test the password against instead of a single hash'"`
+	PasswordFlags
+	WorkerFlags
+	VerifyFlags
+	PepperFlags
+	HMACFlags
 }
 
-// Run the check command.
-func (cmd *CheckCmd) Run(functions map[string]pwhash.Function) error {
-	var fmtMatches []string
-	var passMatches []string
-	for id, f := range functions {
-		queryHash, salt, cost, err := f.Parse([]byte(cmd.EncodedHash))
-		if err != nil {
+// VerifyFlags control the verification of untrusted password hashes.
+type VerifyFlags struct {
+	MaxCost   map[string]uint `kong:"placeholder='FUNCTION=COST',help='Maximum permitted cost of a hash using the given function. Functions without a maximum are permitted 16 times their default cost.'"`
+	NoMaxCost bool            `kong:"help='Permit any cost for functions without a maximum given by --max-cost. Only use this for hashes from a trusted source, since a high cost hash can take a very long time to verify.'"`
+}
+
+// options returns the pwhash.VerifyOptions represented by the flags.
+func (vf *VerifyFlags) options() *pwhash.VerifyOptions {
+	return &pwhash.VerifyOptions{MaxCost: vf.MaxCost,
+		NoDefaultMaxCost: vf.NoMaxCost}
+}
+
+// WorkerFlags control the parallelism of batch verification.
+type WorkerFlags struct {
+	Workers int `kong:"default='0',help='Number of concurrent workers. Defaults to GOMAXPROCS.'"`
+}
+
+// checkResult is the outcome of testing a password against an encoded hash
+// with each matching function.
+type checkResult struct {
+	encodedHash string
+	// matches are the IDs of the functions for which the password matches
+	matches []string
+	// err is the first error returned by verification, if any
+	err error
+}
+
+// add records the outcome of verification using the given function.
+func (r *checkResult) add(f pwhash.Function, match bool, err error) {
+	switch {
+	case err != nil:
+		if r.err == nil {
+			r.err = fmt.Errorf("couldn't verify password using %s: %w", f.ID(), err)
+		}
+	case match:
+		r.matches = append(r.matches, f.ID())
+	}
+}
+
+// checkHashes tests the password against each of the given encoded hashes
+// with every matching function in parallel, and calls report with the result
+// for each hash in order. Each hash is prepared once per matching function
+// before any password is hashed. Hashes with an unknown format are skipped
+// with a warning.
+func (cmd *CheckCmd) checkHashes(ctx context.Context,
+	functions map[string]pwhash.Function, encodedHashes []string,
+	password []byte, opts *pwhash.VerifyOptions, report func(*checkResult)) {
+	// results is indexed by the position of the hash, and is nil for hashes
+	// with an unknown format
+	results := make([]*checkResult, len(encodedHashes))
+	var jobs []batch.Job
+	for i, encodedHash := range encodedHashes {
+		matches := pwhash.Identify(functions, []byte(encodedHash))
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "skipping hash with unknown format: %s\n",
+				encodedHash)
 			continue
 		}
-		fmtMatches = append(fmtMatches, id)
-		calculatedHash, err := f.Hash([]byte(cmd.Password), salt, cost)
-		if err != nil {
-			return fmt.Errorf("couldn't hash password using %s: %v", id, err)
+		results[i] = &checkResult{encodedHash: encodedHash}
+		for _, f := range matches {
+			p, err := pwhash.Prepare(f, []byte(encodedHash), opts)
+			if err != nil {
+				results[i].add(f, false, err)
+				continue
+			}
+			jobs = append(jobs, batch.Job{Hash: p, Password: password, Tag: i})
 		}
-		if subtle.ConstantTimeCompare(queryHash, calculatedHash) == 1 {
-			passMatches = append(passMatches, id)
+	}
+	c := make(chan batch.Job)
+	go func() {
+		defer close(c)
+		for _, job := range jobs {
+			select {
+			case c <- job:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
+	// results arrive in order, so when a result for a hash arrives the
+	// results of the preceding hashes are complete
+	var next int
+	flush := func(end int) {
+		for ; next < end; next++ {
+			if results[next] != nil {
+				report(results[next])
+			}
+		}
+	}
+	engine := batch.Engine{Workers: cmd.Workers}
+	for r := range engine.Run(ctx, c) {
+		flush(r.Job.Tag)
+		results[r.Job.Tag].add(r.Job.Hash.Function, r.Match, r.Err)
+	}
+	if ctx.Err() == nil {
+		flush(len(results))
+	}
+}
+
+// Run the check command.
+func (cmd *CheckCmd) Run(ctx context.Context,
+	functions map[string]pwhash.Function) error {
+	if cmd.Hashes != "" && cmd.EncodedHash != nil {
+		return errors.New("--hashes can't be used with a hash argument: " +
+			"use --stdin or the prompt to give the password")
+	}
+	if cmd.Hashes == "" && cmd.EncodedHash == nil {
+		return errors.New("no hash given: pass a hash argument or --hashes")
+	}
+	var err error
+	if functions, err = cmd.withHMAC(functions); err != nil {
+		return err
 	}
-	if len(fmtMatches) == 0 {
+	opts := cmd.options()
+	if opts.Peppers, err = cmd.peppers(); err != nil {
+		return err
+	}
+	if cmd.Hashes != "" {
+		return cmd.runBatch(ctx, functions, opts)
+	}
+	password, err := cmd.password(ctx, cmd.Password, false)
+	if err != nil {
+		return err
+	}
+	matches := pwhash.Identify(functions, []byte(*cmd.EncodedHash))
+	if len(matches) == 0 {
 		return fmt.Errorf("no matching hash format")
 	}
 	fmt.Println("Matching hash formats:")
-	for _, m := range fmtMatches {
-		fmt.Printf("* %s\n", m)
+	for _, f := range matches {
+		fmt.Printf("* %s\n", f.ID())
 	}
-	if len(passMatches) == 0 {
+	var result *checkResult
+	cmd.checkHashes(ctx, functions, []string{*cmd.EncodedHash}, password, opts,
+		func(r *checkResult) { result = r })
+	if err = ctx.Err(); err != nil {
+		return fmt.Errorf("check cancelled: %w", err)
+	}
+	if result.err != nil {
+		return result.err
+	}
+	if len(result.matches) == 0 {
 		return fmt.Errorf("no valid password found for any matching hash formats")
 	}
 	fmt.Println("Password matches hash for:")
-	for _, m := range passMatches {
+	for _, m := range result.matches {
 		fmt.Printf("* %s\n", m)
 	}
 	return nil
 }
+
+// runBatch tests the password against each hash in the hashes file, and
+// prints the result for each hash as it becomes available.
+func (cmd *CheckCmd) runBatch(ctx context.Context,
+	functions map[string]pwhash.Function, opts *pwhash.VerifyOptions) error {
+	encodedHashes, err := readLines(cmd.Hashes)
+	if err != nil {
+		return fmt.Errorf("couldn't read hashes: %v", err)
+	}
+	password, err := cmd.password(ctx, nil, false)
+	if err != nil {
+		return err
+	}
+	var checked, matched int
+	cmd.checkHashes(ctx, functions, encodedHashes, password, opts,
+		func(r *checkResult) {
+			checked++
+			switch {
+			case len(r.matches) > 0:
+				matched++
+				fmt.Printf("match: %s (%s)\n", r.encodedHash, r.matches[0])
+			case r.err != nil:
+				fmt.Printf("error: %s: %v\n", r.encodedHash, r.err)
+			default:
+				fmt.Printf("no match: %s\n", r.encodedHash)
+			}
+		})
+	if err = ctx.Err(); err != nil {
+		return fmt.Errorf("check cancelled: %w", err)
+	}
+	if matched < checked {
+		return fmt.Errorf("%d of %d hashes don't match the password",
+			checked-matched, checked)
+	}
+	return nil
+}
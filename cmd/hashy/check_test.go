@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+func TestCheck(t *testing.T) {
+	var testCases = map[string]struct {
+		encodedHash string
+		password    string
+		maxCost     map[string]uint
+		noMaxCost   bool
+		expectErr   error
+	}{
+		"match": {
+			encodedHash: `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			password:    "hashcat",
+		},
+		"default max cost exceeded": {
+			encodedHash: `$5$rounds=100000$GX7BopJZJxPc/KEK$B0.otSf8vrLEEGD/pM4aaZMj/gpn3R9yyoAXvNAFQY/`,
+			password:    "hashcat",
+			expectErr:   pwhash.ErrCostLimit,
+		},
+		"max cost": {
+			encodedHash: `$5$rounds=100000$GX7BopJZJxPc/KEK$B0.otSf8vrLEEGD/pM4aaZMj/gpn3R9yyoAXvNAFQY/`,
+			password:    "hashcat",
+			maxCost:     map[string]uint{"sha256crypt": 100000},
+		},
+		"no max cost": {
+			encodedHash: `$5$rounds=100000$GX7BopJZJxPc/KEK$B0.otSf8vrLEEGD/pM4aaZMj/gpn3R9yyoAXvNAFQY/`,
+			password:    "hashcat",
+			noMaxCost:   true,
+		},
+		"max cost exceeded": {
+			encodedHash: `$5$rounds=100000$GX7BopJZJxPc/KEK$B0.otSf8vrLEEGD/pM4aaZMj/gpn3R9yyoAXvNAFQY/`,
+			password:    "hashcat",
+			maxCost:     map[string]uint{"sha256crypt": 80000},
+			expectErr:   pwhash.ErrCostLimit,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			cmd := CheckCmd{
+				EncodedHash: &tc.encodedHash,
+				Password:    &tc.password,
+				// kong sets the default of -1
+				PasswordFlags: PasswordFlags{PasswordFD: -1},
+				VerifyFlags: VerifyFlags{
+					MaxCost:   tc.maxCost,
+					NoMaxCost: tc.noMaxCost,
+				},
+			}
+			err := cmd.Run(context.Background(), registry())
+			if !errors.Is(err, tc.expectErr) {
+				tt.Fatalf("expected err %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
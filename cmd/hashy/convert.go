@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/smlx/hashy/pkg/convert"
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// ConvertCmd represents the convert command.
+type ConvertCmd struct {
+	To     string   `kong:"required,enum='crypt,hashcat,john',help='Syntax to convert hashes to (crypt,hashcat,john)'"`
+	Format string   `kong:"help='ID of the hash format (see the id command). Defaults to the most likely format of each hash.'"`
+	Hashes []string `kong:"optional,arg,help='Password hashes in crypt, hashcat or John the Ripper syntax, optionally preceded by a username and colon. If not given, hashes are read from stdin, one per line.'"`
+}
+
+// Run the convert command.
+func (cmd *ConvertCmd) Run(functions map[string]pwhash.Function) error {
+	target, err := convert.ParseTarget(cmd.To)
+	if err != nil {
+		return err
+	}
+	lines := cmd.Hashes
+	if len(lines) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if len(scanner.Text()) > 0 {
+				lines = append(lines, scanner.Text())
+			}
+		}
+		if err = scanner.Err(); err != nil {
+			return fmt.Errorf("couldn't read hashes: %v", err)
+		}
+	}
+	// modes maps format IDs to the hashcat or john mode of converted hashes
+	modes := map[string]string{}
+	var failed int
+	for _, line := range lines {
+		h, err := convert.Parse(line, cmd.Format, functions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping hash: %v: %s\n", err, line)
+			failed++
+			continue
+		}
+		converted, err := h.Convert(target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping hash: %v: %s\n", err, line)
+			failed++
+			continue
+		}
+		fmt.Println(converted)
+		switch target {
+		case convert.Hashcat:
+			mode := fmt.Sprintf("hashcat -m %d", h.Format.Hashcat)
+			// hashcat only accepts usernames if requested
+			if h.User != "" || strings.HasSuffix(modes[h.Format.ID], "--username") {
+				mode += " --username"
+			}
+			modes[h.Format.ID] = mode
+		case convert.John:
+			modes[h.Format.ID] = fmt.Sprintf("john --format=%s", h.Format.John)
+		}
+	}
+	// describe the modes on stderr so that stdout can be redirected to a file
+	var ids []string
+	for id := range modes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", id, modes[id])
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d hashes couldn't be converted", failed)
+	}
+	return nil
+}
@@ -1,41 +1,86 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/smlx/hashy/pkg/provision"
 	"github.com/smlx/hashy/pkg/pwhash"
 )
 
 // GenerateCmd represents the generate command.
 type GenerateCmd struct {
-	Function string `kong:"required,enum='mariaDBOldPassword,md5crypt,sha1crypt,sha256crypt,sha512crypt',help='Cryptographic hash function (AKA method) used to generate the password hash'"`
-	Cost     uint   `kong:"help='CPU time cost. This parameter has a different meaning for each cryptographic hash function.'"`
-	Password string `kong:"required,arg,help='Password to hash'"`
+	Function string  `kong:"required,enum='mariaDBOldPassword,md5crypt,sha1crypt,sha256crypt,sha512crypt',help='Cryptographic hash function (AKA method) used to generate the password hash'"`
+	Cost     uint    `kong:"help='CPU time cost. This parameter has a different meaning for each cryptographic hash function.'"`
+	Salt     string  `kong:"help='Salt to use instead of a randomly generated one. It must be valid for the given function.'"`
+	Wrap     string  `kong:"enum='mariaDBOldPassword,md5crypt,sha1crypt,',default='',help='Legacy inner function to wrap inside the given sha256crypt or sha512crypt function, to generate a hash compatible with hashes upgraded by the wrap command'"`
+	Output   string  `kong:"enum='hash,chpasswd,usermod,cloud-init,ansible',default='hash',help='Output template: the bare hash, a user:hash line for chpasswd -e, a usermod -p command, a cloud-init users entry, or an Ansible user task (${enum})'"`
+	User     string  `kong:"help='Username for the output template'"`
+	Password *string `kong:"optional,arg,help='Password to hash. If not given, it is read according to the flags below or prompted for.'"`
+	PasswordFlags
+	PepperFlags
+	StrengthFlags
+	RandomFlags
 }
 
 // Run the generate command.
-func (cmd *GenerateCmd) Run(functions map[string]pwhash.Function) error {
+func (cmd *GenerateCmd) Run(ctx context.Context,
+	functions map[string]pwhash.Function) error {
 	// get the function
-	f, ok := functions[cmd.Function]
+	id := cmd.Function
+	if cmd.Wrap != "" {
+		id = fmt.Sprintf("%s(%s)", cmd.Function, cmd.Wrap)
+	}
+	f, ok := functions[id]
 	if !ok {
-		return fmt.Errorf("unknown funciton %s", cmd.Function)
+		return fmt.Errorf("unknown funciton %s", id)
+	}
+	template, err := provision.ParseTemplate(cmd.Output)
+	if err != nil {
+		return err
+	}
+	// check the username before reading the password
+	if _, err = provision.Format(template, cmd.User, ""); err != nil {
+		return err
+	}
+	// get the password
+	if cmd.Password != nil && (cmd.Random || cmd.Diceware > 0) {
+		return fmt.Errorf("can't use a password argument with a random password")
+	}
+	password, bits, err := cmd.randomPassword()
+	if err != nil {
+		return err
+	}
+	random := password != nil
+	if !random {
+		if password, err = cmd.password(ctx, cmd.Password, true); err != nil {
+			return err
+		}
+	}
+	if err = cmd.checkStrength(password); err != nil {
+		return err
 	}
-	// get a salt
-	salt, err := f.GenerateSalt()
+	pepper, err := cmd.pepper()
 	if err != nil {
-		return fmt.Errorf("couldn't generate salt: %v", err)
+		return err
 	}
-	// use the default cost if none was passed
-	cost := cmd.Cost
-	if cost == 0 {
-		cost = f.DefaultCost()
+	opts := pwhash.GenerateOptions{Cost: cmd.Cost, Pepper: pepper}
+	// use the given salt, if any
+	if cmd.Salt != "" {
+		opts.Salt = []byte(cmd.Salt)
 	}
-	// generate a hash
-	pwHash, err := f.Hash([]byte(cmd.Password), salt, cost)
+	encodedHash, err := pwhash.Generate(ctx, f, password, &opts)
 	if err != nil {
-		return fmt.Errorf("couldn't hash password: %v", err)
+		return err
 	}
 	// format output
-	_, err = fmt.Println(f.Format(pwHash, salt, cost))
+	out, err := provision.Format(template, cmd.User, encodedHash)
+	if err != nil {
+		return err
+	}
+	if random {
+		printRandomPassword(password, bits)
+	}
+	_, err = fmt.Println(out)
 	return err
 }
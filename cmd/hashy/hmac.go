@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/hmac"
+)
+
+// HMACFlags control the server-side secret key of HMAC hashes.
+type HMACFlags struct {
+	HMACKeyFile string `kong:"type='existingfile',help='File containing the secret key of HMAC hashes of the password, such as hmac-sha256(k,p)'"`
+}
+
+// withHMAC returns the given functions, extended with the HMAC functions
+// keyed by the secret in the HMAC key file if one was given.
+func (hf *HMACFlags) withHMAC(
+	functions map[string]pwhash.Function) (map[string]pwhash.Function, error) {
+	if hf.HMACKeyFile == "" {
+		return functions, nil
+	}
+	data, err := os.ReadFile(hf.HMACKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read HMAC key file: %v", err)
+	}
+	key := bytes.TrimRight(data, "\r\n")
+	if len(key) == 0 {
+		return nil, fmt.Errorf("empty HMAC key")
+	}
+	extended := map[string]pwhash.Function{}
+	for id, f := range functions {
+		extended[id] = f
+	}
+	for _, f := range hmac.SecretFunctions(key) {
+		extended[f.ID()] = f
+	}
+	return extended, nil
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/smlx/hashy/pkg/identify"
 	"github.com/smlx/hashy/pkg/pwhash"
 )
 
@@ -11,19 +13,72 @@ type IDCmd struct {
 	EncodedHash string `kong:"required,arg,help='Password hash in encoded format'"`
 }
 
-// Run the id command.
-func (cmd *IDCmd) Run(functions map[string]pwhash.Function) error {
-	var matches []string
-	for id, f := range functions {
-		_, _, _, err := f.Parse([]byte(cmd.EncodedHash))
-		if err == nil {
-			matches = append(matches, id)
+// describe returns a one-line description of the given candidate.
+func describe(c identify.Candidate) string {
+	details := []string{fmt.Sprintf("%s confidence", c.Confidence)}
+	if c.Hashcat >= 0 {
+		details = append(details, fmt.Sprintf("hashcat -m %d", c.Hashcat))
+	}
+	if c.John != "" {
+		details = append(details, fmt.Sprintf("john --format=%s", c.John))
+	}
+	if c.Function == nil {
+		details = append(details, "not supported by hashy")
+	}
+	if c.Name == c.ID {
+		return fmt.Sprintf("%s: %s", c.ID, strings.Join(details, ", "))
+	}
+	return fmt.Sprintf("%s (%s): %s", c.ID, c.Name, strings.Join(details, ", "))
+}
+
+// report returns a description of the strength of the given encoded hash
+// of the given function, one detail per line.
+func report(f pwhash.Function, encodedHash string) ([]string, error) {
+	s, err := pwhash.Assess(f, []byte(encodedHash))
+	if err != nil {
+		return nil, err
+	}
+	lines := []string{"salt: none"}
+	if s.SaltBits > 0 {
+		lines[0] = fmt.Sprintf("salt: %.0f bits of entropy at most", s.SaltBits)
+	}
+	if s.DefaultCost > 0 {
+		lines = append(lines, fmt.Sprintf("cost: %d (%.2fx default of %d)",
+			s.Cost, s.CostRatio(), s.DefaultCost))
+	} else {
+		lines = append(lines, "cost: not configurable")
+	}
+	switch {
+	case s.Verdict == nil:
+		lines = append(lines, "verdict: unknown")
+	case s.Verdict.Weak:
+		lines = append(lines, fmt.Sprintf("verdict: weak, %s", s.Verdict.Reason))
+		for _, ref := range s.Verdict.References {
+			lines = append(lines, fmt.Sprintf("  see %s", ref))
 		}
+	default:
+		lines = append(lines, "verdict: no known weakness")
 	}
-	if len(matches) > 0 {
+	return lines, nil
+}
+
+// Run the id command.
+func (cmd *IDCmd) Run(functions map[string]pwhash.Function) error {
+	candidates := identify.Identify([]byte(cmd.EncodedHash), functions)
+	if len(candidates) > 0 {
 		fmt.Println("Matching hash formats:")
-		for _, m := range matches {
-			fmt.Printf("* %s\n", m)
+		for _, c := range candidates {
+			fmt.Printf("* %s\n", describe(c))
+			if c.Function == nil {
+				continue
+			}
+			lines, err := report(c.Function, cmd.EncodedHash)
+			if err != nil {
+				return err
+			}
+			for _, line := range lines {
+				fmt.Printf("    %s\n", line)
+			}
 		}
 		return nil
 	}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
 	"github.com/alecthomas/kong"
 	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/hmac"
 	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
 	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/rawdigest"
 	"github.com/smlx/hashy/pkg/pwhash/sha1crypt"
 	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
 	"github.com/smlx/hashy/pkg/pwhash/sha512crypt"
+	"github.com/smlx/hashy/pkg/pwhash/wrapped"
 )
 
 var (
@@ -19,25 +29,75 @@ var (
 
 // CLI represents the command-line interface.
 type CLI struct {
+	Timeout time.Duration `kong:"help='Abandon the command if it takes longer than this duration'"`
+
 	ID       IDCmd       `kong:"cmd,help='Identify a password hash'"`
 	Check    CheckCmd    `kong:"cmd,help='Check a password against a hash'"`
 	Generate GenerateCmd `kong:"cmd,help='Generate a hash from a password'"`
+	Mkpasswd MkpasswdCmd `kong:"cmd,help='Generate a hash from a password using mkpasswd-compatible flags'"`
+	Audit    AuditCmd    `kong:"cmd,help='Check a list of hashes against a wordlist of passwords'"`
+	Bench    BenchCmd    `kong:"cmd,help='Recommend a cost for each function based on the speed of this machine'"`
+	Serve    ServeCmd    `kong:"cmd,help='Serve a local HTTP API to identify, verify and generate hashes'"`
+	Wrap     WrapCmd     `kong:"cmd,help='Wrap an existing hash inside a stronger hash'"`
+	Convert  ConvertCmd  `kong:"cmd,help='Convert hashes between crypt, hashcat and John the Ripper syntax'"`
 	Version  VersionCmd  `kong:"cmd,help='Print version information'"`
 }
 
+// registry returns the supported functions, keyed by ID.
+func registry() map[string]pwhash.Function {
+	legacy := []pwhash.Function{
+		&mariadboldpassword.Function{},
+		&md5crypt.Function{},
+		&sha1crypt.Function{},
+	}
+	strong := []pwhash.Function{
+		&sha256crypt.Function{},
+		&sha512crypt.Function{},
+	}
+	functions := map[string]pwhash.Function{}
+	for _, f := range append(legacy, strong...) {
+		functions[f.ID()] = f
+	}
+	// register each legacy function wrapped in each strong function
+	for _, outer := range strong {
+		for _, inner := range legacy {
+			f := wrapped.New(inner, outer)
+			functions[f.ID()] = f
+		}
+	}
+	// register the salted raw digests and HMACs
+	for _, f := range rawdigest.Functions() {
+		functions[f.ID()] = f
+	}
+	for _, f := range hmac.Functions() {
+		functions[f.ID()] = f
+	}
+	return functions
+}
+
 func main() {
 	// parse CLI config
 	cli := CLI{}
-	kctx := kong.Parse(&cli,
+	parser := kong.Must(&cli,
 		kong.UsageOnError(),
 	)
-	functions := map[string]pwhash.Function{
-		mariadboldpassword.ID: &mariadboldpassword.Function{},
-		md5crypt.ID:           &md5crypt.Function{},
-		sha1crypt.ID:          &sha1crypt.Function{},
-		sha256crypt.ID:        &sha256crypt.Function{},
-		sha512crypt.ID:        &sha512crypt.Function{},
+	// behave like mkpasswd if invoked by that name
+	args := os.Args[1:]
+	if filepath.Base(os.Args[0]) == "mkpasswd" {
+		args = append([]string{"mkpasswd"}, args...)
+	}
+	kctx, err := parser.Parse(args)
+	parser.FatalIfErrorf(err)
+	// cancel the command on interrupt or timeout
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt,
+		syscall.SIGTERM)
+	defer stop()
+	if cli.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cli.Timeout)
+		defer cancel()
 	}
+	kctx.BindTo(ctx, (*context.Context)(nil))
 	// execute CLI
-	kctx.FatalIfErrorf(kctx.Run(functions))
+	kctx.FatalIfErrorf(kctx.Run(registry()))
 }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/smlx/hashy/pkg/convert"
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha512crypt"
+)
+
+// mkpasswdMethod maps a mkpasswd method name onto a hash function ID.
+type mkpasswdMethod struct {
+	name string
+	// desc is empty for aliases, which are not listed by "-m help".
+	desc string
+	id   string
+}
+
+// mkpasswdMethods are the methods accepted by the mkpasswd command, in the
+// order they are listed by "-m help". They are the subset of the methods of
+// mkpasswd which are supported by hashy, with the same names and descriptions.
+var mkpasswdMethods = []mkpasswdMethod{
+	{name: "sha512crypt", desc: "SHA-512", id: sha512crypt.ID},
+	{name: "sha256crypt", desc: "SHA-256", id: sha256crypt.ID},
+	{name: "md5crypt", desc: "MD5", id: md5crypt.ID},
+	{name: "sha-512", id: sha512crypt.ID},
+	{name: "sha-256", id: sha256crypt.ID},
+	{name: "md5", id: md5crypt.ID},
+}
+
+// MkpasswdCmd represents the mkpasswd command. It accepts the same flags as
+// the mkpasswd tool distributed with whois.
+type MkpasswdCmd struct {
+	Method   string  `kong:"short='m',default='sha512crypt',placeholder='TYPE',help='Select method TYPE. Use \"-m help\" to list the available methods.'"`
+	SaltFlag string  `kong:"name='salt',short='S',placeholder='SALT',help='Use the given salt'"`
+	Rounds   uint    `kong:"short='R',placeholder='NUMBER',help='Use the given number of rounds'"`
+	Password *string `kong:"optional,arg,help='Password to hash. If not given, it is read according to the flags below or prompted for.'"`
+	SaltArg  string  `kong:"name='salt',optional,arg,help='Use the given salt'"`
+	PasswordFlags
+}
+
+// printMethods writes the list of methods printed by "-m help" to w, in the
+// same layout as mkpasswd. Only the methods supported by hashy are listed.
+func printMethods(w io.Writer) {
+	fmt.Fprintln(w, "Available methods:")
+	for _, m := range mkpasswdMethods {
+		if m.desc != "" {
+			fmt.Fprintf(w, "%-15s %s\n", m.name, m.desc)
+		}
+	}
+}
+
+// Run the mkpasswd command.
+func (cmd *MkpasswdCmd) Run(ctx context.Context,
+	functions map[string]pwhash.Function) error {
+	if cmd.Method == "help" {
+		printMethods(os.Stdout)
+		return nil
+	}
+	// get the function
+	var f pwhash.Function
+	for _, m := range mkpasswdMethods {
+		if m.name == cmd.Method {
+			f = functions[m.id]
+			break
+		}
+	}
+	if f == nil {
+		return fmt.Errorf("invalid method '%s'", cmd.Method)
+	}
+	// get the salt, if any
+	var salt []byte
+	switch {
+	case cmd.SaltFlag != "":
+		salt = []byte(cmd.SaltFlag)
+	case cmd.SaltArg != "":
+		salt = []byte(cmd.SaltArg)
+	}
+	// get the password
+	password, err := cmd.password(ctx, cmd.Password, false)
+	if err != nil {
+		return err
+	}
+	encodedHash, err := mkpasswd(ctx, f, password, salt, cmd.Rounds)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(encodedHash)
+	return err
+}
+
+// mkpasswd generates a hash of the password using f in the same way as the
+// mkpasswd tool. The number of rounds is ignored if f has a fixed cost, and
+// is otherwise clamped to the range supported by f. If rounds is zero the
+// default cost is used, and the rounds parameter is omitted from
+// sha256crypt and sha512crypt hashes, as it is by crypt().
+func mkpasswd(ctx context.Context, f pwhash.Function, password, salt []byte,
+	rounds uint) (string, error) {
+	if vc, ok := f.(pwhash.VariableCost); !ok {
+		rounds = 0
+	} else if rounds != 0 {
+		costMin, costMax := vc.CostRange()
+		switch {
+		case rounds < costMin:
+			rounds = costMin
+		case rounds > costMax:
+			rounds = costMax
+		}
+	}
+	encodedHash, err := pwhash.Generate(ctx, f, password,
+		&pwhash.GenerateOptions{Salt: salt, Cost: rounds})
+	if err != nil {
+		return "", err
+	}
+	if rounds == 0 {
+		return convert.CryptForm(f, encodedHash), nil
+	}
+	return encodedHash, nil
+}
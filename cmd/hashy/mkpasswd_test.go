@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha512crypt"
+)
+
+func TestMkpasswd(t *testing.T) {
+	var testCases = map[string]struct {
+		function pwhash.Function
+		salt     string
+		rounds   uint
+		expect   string
+	}{
+		"sha256crypt default rounds": {
+			function: &sha256crypt.Function{},
+			salt:     "GX7BopJZJxPc/KEK",
+			expect:   `$5$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+		},
+		"sha256crypt explicit default rounds": {
+			function: &sha256crypt.Function{},
+			salt:     "GX7BopJZJxPc/KEK",
+			rounds:   5000,
+			expect:   `$5$rounds=5000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+		},
+		"sha512crypt clamped rounds": {
+			function: &sha512crypt.Function{},
+			salt:     "zrr5Kt7jpmLAHTeX",
+			rounds:   10,
+			expect:   `$6$rounds=1000$zrr5Kt7jpmLAHTeX$E1ymN1CYcpw2OuJJeMSBWEZ/bbNEZ4Hv10JkO6gjRoGQzKGtQOeIqZ7iwXCo7T61UtKMR0nyxZxCsMZRI8KAx.`,
+		},
+		"md5crypt ignored rounds": {
+			function: &md5crypt.Function{},
+			salt:     "28772684",
+			rounds:   10000,
+			expect:   `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			encodedHash, err := mkpasswd(context.Background(), tc.function,
+				[]byte("hashcat"), []byte(tc.salt), tc.rounds)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if encodedHash != tc.expect {
+				tt.Fatalf("expected %s, got %s", tc.expect, encodedHash)
+			}
+		})
+	}
+}
+
+func TestPrintMethods(t *testing.T) {
+	var buf bytes.Buffer
+	printMethods(&buf)
+	// the layout of mkpasswd -m help, listing the methods supported by hashy
+	expect := "Available methods:\n" +
+		"sha512crypt     SHA-512\n" +
+		"sha256crypt     SHA-256\n" +
+		"md5crypt        MD5\n"
+	if buf.String() != expect {
+		t.Fatalf("expected\n%s\ngot\n%s", expect, buf.String())
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// PasswordFlags control how a password is read when it is not passed as an
+// argument.
+type PasswordFlags struct {
+	Stdin      bool `kong:"short='s',help='Read the password from stdin'"`
+	PasswordFD int  `kong:"short='P',default='-1',placeholder='NUM',help='Read the password from the given file descriptor'"`
+}
+
+// readLine reads a single line from r, stripping the trailing newline.
+func readLine(r io.Reader) ([]byte, error) {
+	line, err := bufio.NewReader(r).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r")),
+		nil
+}
+
+// readContext calls read and returns its result, or returns an error wrapping
+// ctx.Err() if ctx is done first.
+//
+// If ctx is done first, the goroutine calling read is left blocked until read
+// returns: a blocking read of a terminal or pipe can't be interrupted
+// portably, and closing the file doesn't unblock it. Any password it reads
+// later is discarded. This is only acceptable because the commands read the
+// password once and exit with the cancellation error, which ends the
+// goroutine, so readContext must not be used by long-running commands such as
+// serve.
+func readContext(ctx context.Context, read func() ([]byte, error)) ([]byte,
+	error) {
+	type result struct {
+		password []byte
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		password, err := read()
+		done <- result{password: password, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.password, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("read cancelled: %w", ctx.Err())
+	}
+}
+
+// prompt writes the given prompt to stderr and reads a password from the
+// terminal on stdin without echo.
+func prompt(ctx context.Context, p string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	// save the terminal state so echo can be restored if ctx is done
+	state, err := term.GetState(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(fd, state) //nolint:errcheck
+	fmt.Fprint(os.Stderr, p)
+	password, err := readContext(ctx, func() ([]byte, error) {
+		return term.ReadPassword(fd)
+	})
+	fmt.Fprintln(os.Stderr)
+	return password, err
+}
+
+// password returns the password passed as an argument, or reads it according
+// to the PasswordFlags. If neither flag is set and stdin is a terminal the
+// user is prompted for the password. If confirm is true the user must enter
+// the password twice at the prompt. It is an error to pass the password in
+// more than one way.
+func (pf *PasswordFlags) password(ctx context.Context, arg *string,
+	confirm bool) ([]byte, error) {
+	switch {
+	case arg != nil && (pf.Stdin || pf.PasswordFD >= 0):
+		return nil, fmt.Errorf(
+			"password argument can't be used with --stdin or --password-fd")
+	case pf.Stdin && pf.PasswordFD >= 0:
+		return nil, fmt.Errorf("--stdin can't be used with --password-fd")
+	case arg != nil:
+		return []byte(*arg), nil
+	case pf.PasswordFD >= 0:
+		f := os.NewFile(uintptr(pf.PasswordFD), "password-fd")
+		if f == nil {
+			return nil, fmt.Errorf("invalid file descriptor %d", pf.PasswordFD)
+		}
+		defer f.Close()
+		password, err := readContext(ctx, func() ([]byte, error) {
+			return readLine(f)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read password from fd %d: %w",
+				pf.PasswordFD, err)
+		}
+		return password, nil
+	case pf.Stdin:
+		stdin := os.Stdin
+		password, err := readContext(ctx, func() ([]byte, error) {
+			return readLine(stdin)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read password from stdin: %w", err)
+		}
+		return password, nil
+	case term.IsTerminal(int(os.Stdin.Fd())):
+		password, err := prompt(ctx, "Password: ")
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read password: %w", err)
+		}
+		if !confirm {
+			return password, nil
+		}
+		again, err := prompt(ctx, "Confirm password: ")
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read password: %w", err)
+		}
+		if !bytes.Equal(password, again) {
+			return nil, fmt.Errorf("passwords do not match")
+		}
+		return password, nil
+	default:
+		return nil, fmt.Errorf(
+			"no password given and stdin is not a terminal: use --stdin")
+	}
+}
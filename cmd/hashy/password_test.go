@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestPassword(t *testing.T) {
+	arg := "hashcat"
+	var testCases = map[string]struct {
+		flags     PasswordFlags
+		arg       *string
+		stdin     string
+		expect    string
+		expectErr bool
+	}{
+		"argument": {
+			flags:  PasswordFlags{PasswordFD: -1},
+			arg:    &arg,
+			expect: "hashcat",
+		},
+		"stdin": {
+			flags:  PasswordFlags{Stdin: true, PasswordFD: -1},
+			stdin:  "hashcat\n",
+			expect: "hashcat",
+		},
+		"stdin crlf": {
+			flags:  PasswordFlags{Stdin: true, PasswordFD: -1},
+			stdin:  "hashcat\r\n",
+			expect: "hashcat",
+		},
+		"argument and stdin": {
+			flags:     PasswordFlags{Stdin: true, PasswordFD: -1},
+			arg:       &arg,
+			stdin:     "other\n",
+			expectErr: true,
+		},
+		"argument and fd": {
+			flags:     PasswordFlags{PasswordFD: 0},
+			arg:       &arg,
+			expectErr: true,
+		},
+		"stdin and fd": {
+			flags:     PasswordFlags{Stdin: true, PasswordFD: 0},
+			expectErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				tt.Fatal(err)
+			}
+			defer r.Close()
+			if _, err = w.WriteString(tc.stdin); err != nil {
+				tt.Fatal(err)
+			}
+			w.Close()
+			stdin := os.Stdin
+			os.Stdin = r
+			defer func() { os.Stdin = stdin }()
+			password, err := tc.flags.password(context.Background(), tc.arg, false)
+			if tc.expectErr {
+				if err == nil {
+					tt.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if string(password) != tc.expect {
+				tt.Fatalf("expected %q, got %q", tc.expect, password)
+			}
+		})
+	}
+}
+
+func TestPasswordCancel(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	// closing the write end ends the blocked read
+	defer w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pf := PasswordFlags{Stdin: true, PasswordFD: -1}
+	if _, err = pf.password(ctx, nil, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
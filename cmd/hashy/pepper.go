@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// PepperFlags control the peppers (server-side secrets) applied to passwords.
+// Several peppers may be given so that hashes peppered with old keys can
+// still be verified after rotation, but hashes are generated with one.
+type PepperFlags struct {
+	PepperFile map[string]string `kong:"placeholder='ID=FILE',help='File containing the pepper key with the given ID. May be repeated to verify hashes peppered with old keys.'"`
+	PepperEnv  map[string]string `kong:"placeholder='ID=VAR',help='Environment variable containing the pepper key with the given ID. May be repeated to verify hashes peppered with old keys.'"`
+	PepperID   string            `kong:"help='ID of the pepper key used to generate hashes. Required if more than one pepper key is given.'"`
+}
+
+// peppers returns the peppers represented by the flags, sorted by ID, for use
+// in pwhash.VerifyOptions. It returns nil if no pepper keys were given.
+func (pf *PepperFlags) peppers() ([]pwhash.Pepper, error) {
+	var peppers []pwhash.Pepper
+	seen := map[string]bool{}
+	add := func(id string, key []byte, source string) error {
+		if seen[id] {
+			return fmt.Errorf("pepper ID %q given more than once", id)
+		}
+		seen[id] = true
+		if len(key) == 0 {
+			return fmt.Errorf("empty pepper key %q in %s", id, source)
+		}
+		peppers = append(peppers, pwhash.Pepper{ID: id, Key: key})
+		return nil
+	}
+	for id, name := range pf.PepperFile {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read pepper file: %v", err)
+		}
+		if err = add(id, bytes.TrimRight(data, "\r\n"), name); err != nil {
+			return nil, err
+		}
+	}
+	for id, name := range pf.PepperEnv {
+		if err := add(id, []byte(os.Getenv(name)), name); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(peppers, func(i, j int) bool {
+		return peppers[i].ID < peppers[j].ID
+	})
+	return peppers, nil
+}
+
+// pepper returns the pepper used to generate hashes: the pepper with the ID
+// given by --pepper-id, or the only pepper if just one was given. It returns
+// nil if no pepper keys were given.
+func (pf *PepperFlags) pepper() (*pwhash.Pepper, error) {
+	peppers, err := pf.peppers()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(peppers) == 0:
+		if pf.PepperID != "" {
+			return nil, fmt.Errorf("--pepper-id given without a pepper key")
+		}
+		return nil, nil
+	case pf.PepperID == "" && len(peppers) == 1:
+		return &peppers[0], nil
+	case pf.PepperID == "":
+		return nil, fmt.Errorf(
+			"more than one pepper key given: use --pepper-id to select one")
+	}
+	for i := range peppers {
+		if peppers[i].ID == pf.PepperID {
+			return &peppers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no pepper key with ID %q", pf.PepperID)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+)
+
+// writeKey writes the given pepper key to a new file and returns its name.
+func writeKey(t *testing.T, key string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "pepper")
+	if err := os.WriteFile(name, []byte(key+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestCheckPepperRotation(t *testing.T) {
+	t.Setenv("HASHY_TEST_PEPPER", "new key")
+	flags := PepperFlags{
+		PepperFile: map[string]string{"old": writeKey(t, "old key")},
+		PepperEnv:  map[string]string{"new": "HASHY_TEST_PEPPER"},
+	}
+	for _, pepper := range []pwhash.Pepper{
+		{ID: "old", Key: []byte("old key")},
+		{ID: "new", Key: []byte("new key")},
+	} {
+		t.Run(pepper.ID, func(tt *testing.T) {
+			encodedHash, err := pwhash.Generate(context.Background(),
+				&md5crypt.Function{}, []byte("hashcat"),
+				&pwhash.GenerateOptions{Pepper: &pepper})
+			if err != nil {
+				tt.Fatal(err)
+			}
+			password := "hashcat"
+			cmd := CheckCmd{
+				EncodedHash: &encodedHash,
+				Password:    &password,
+				// kong sets the default of -1
+				PasswordFlags: PasswordFlags{PasswordFD: -1},
+				PepperFlags:   flags,
+			}
+			if err = cmd.Run(context.Background(), registry()); err != nil {
+				tt.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestPepper(t *testing.T) {
+	oldKey, newKey := writeKey(t, "old key"), writeKey(t, "new key")
+	var testCases = map[string]struct {
+		flags     PepperFlags
+		expectID  string
+		expectErr bool
+	}{
+		"none": {},
+		"one": {
+			flags:    PepperFlags{PepperFile: map[string]string{"old": oldKey}},
+			expectID: "old",
+		},
+		"selected": {
+			flags: PepperFlags{
+				PepperFile: map[string]string{"old": oldKey, "new": newKey},
+				PepperID:   "new",
+			},
+			expectID: "new",
+		},
+		"ambiguous": {
+			flags: PepperFlags{
+				PepperFile: map[string]string{"old": oldKey, "new": newKey},
+			},
+			expectErr: true,
+		},
+		"unknown ID": {
+			flags: PepperFlags{
+				PepperFile: map[string]string{"old": oldKey},
+				PepperID:   "new",
+			},
+			expectErr: true,
+		},
+		"duplicate ID": {
+			flags: PepperFlags{
+				PepperFile: map[string]string{"old": oldKey},
+				PepperEnv:  map[string]string{"old": "HOME"},
+			},
+			expectErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			pepper, err := tc.flags.pepper()
+			if tc.expectErr {
+				if err == nil {
+					tt.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				tt.Fatal(err)
+			}
+			var id string
+			if pepper != nil {
+				id = pepper.ID
+			}
+			if id != tc.expectID {
+				tt.Fatalf("expected pepper %q, got %q", tc.expectID, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/smlx/hashy/pkg/pwgen"
+)
+
+// RandomFlags control the generation of random passwords.
+type RandomFlags struct {
+	Random    bool   `kong:"xor='random',help='Generate a cryptographically random password instead of reading one, and print it once to stderr'"`
+	Length    int    `kong:"default='20',help='Length of the random password'"`
+	Charset   string `kong:"default='alnum',help='Characters of the random password: digits, hex, alpha, alnum, ascii (printable ASCII without space), or a literal set of characters'"`
+	Diceware  int    `kong:"xor='random',placeholder='WORDS',help='Generate a diceware passphrase of the given number of words from the EFF large wordlist instead of reading a password, and print it once to stderr'"`
+	Separator string `kong:"default='-',help='Separator between the words of a diceware passphrase'"`
+}
+
+// randomPassword returns a random password or passphrase according to the
+// flags, and its entropy in bits. It returns nil if no random password was
+// requested.
+func (rf *RandomFlags) randomPassword() ([]byte, float64, error) {
+	var password string
+	var bits float64
+	var err error
+	switch {
+	case rf.Random:
+		var charset string
+		if charset, err = pwgen.Charset(rf.Charset); err != nil {
+			return nil, 0, err
+		}
+		if password, err = pwgen.Random(rf.Length, charset); err != nil {
+			return nil, 0, err
+		}
+		bits = pwgen.RandomBits(rf.Length, charset)
+	case rf.Diceware > 0:
+		if password, err = pwgen.Diceware(rf.Diceware, rf.Separator); err != nil {
+			return nil, 0, err
+		}
+		bits = pwgen.DicewareBits(rf.Diceware)
+	default:
+		return nil, 0, nil
+	}
+	return []byte(password), bits, nil
+}
+
+// printRandomPassword prints the given random password and its entropy once
+// to stderr.
+func printRandomPassword(password []byte, bits float64) {
+	fmt.Fprintf(os.Stderr, "Generated password (%.0f bits of entropy): %s\n",
+		bits, password)
+}
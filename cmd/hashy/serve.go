@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/smlx/hashy/pkg/identify"
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// ServeCmd represents the serve command.
+type ServeCmd struct {
+	Listen          string        `kong:"default='127.0.0.1:8080',help='Address to listen on'"`
+	MaxRequestBytes int64         `kong:"default='65536',help='Maximum size of a request body'"`
+	RequestTimeout  time.Duration `kong:"default='10s',help='Abandon requests which take longer than this duration'"`
+	MaxConcurrent   int           `kong:"default='0',help='Maximum number of requests handled at once. Further requests wait until the request timeout. Defaults to GOMAXPROCS.'"`
+	VerifyFlags
+	PepperFlags
+}
+
+// server handles HTTP requests for the serve command.
+type server struct {
+	functions       map[string]pwhash.Function
+	opts            *pwhash.VerifyOptions
+	pepper          *pwhash.Pepper
+	maxRequestBytes int64
+	requestTimeout  time.Duration
+	// slots limits the number of requests handled at once. A request sends to
+	// slots before it is handled, and receives from it when done.
+	slots chan struct{}
+}
+
+// identifyRequest is the request body of the identify endpoint.
+type identifyRequest struct {
+	Hash string `json:"hash"`
+}
+
+// candidate is a candidate format in the response of the identify endpoint.
+type candidate struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Hashcat    int    `json:"hashcat"`
+	John       string `json:"john,omitempty"`
+	Confidence string `json:"confidence"`
+	Supported  bool   `json:"supported"`
+}
+
+// identifyResponse is the response body of the identify endpoint.
+type identifyResponse struct {
+	Candidates []candidate `json:"candidates"`
+}
+
+// verifyRequest is the request body of the verify endpoint.
+type verifyRequest struct {
+	Hash     string `json:"hash"`
+	Password string `json:"password"`
+}
+
+// verifyResponse is the response body of the verify endpoint.
+type verifyResponse struct {
+	Match    bool   `json:"match"`
+	Function string `json:"function,omitempty"`
+}
+
+// generateRequest is the request body of the generate endpoint.
+type generateRequest struct {
+	Function string `json:"function"`
+	Password string `json:"password"`
+	Cost     uint   `json:"cost,omitempty"`
+}
+
+// generateResponse is the response body of the generate endpoint.
+type generateResponse struct {
+	Hash string `json:"hash"`
+}
+
+// errorResponse is the response body returned on error.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON writes v to w as JSON with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("couldn't write response: %v", err)
+	}
+}
+
+// writeError writes an error response with the given status code.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// statusRecorder records the status code written to a http.ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// handle wraps the given endpoint handler. It restricts requests to POST,
+// decodes the size-limited request body into req, applies the request
+// timeout, limits the number of requests handled at once, and logs the
+// request. Request bodies are never logged since they
+// contain passwords.
+func handle[T any](s *server,
+	endpoint func(context.Context, *T) (int, interface{})) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			log.Printf("%s %s %d %v", r.Method, r.URL.Path, sr.status,
+				time.Since(start))
+		}()
+		if r.Method != http.MethodPost {
+			sr.Header().Set("Allow", http.MethodPost)
+			writeError(sr, http.StatusMethodNotAllowed,
+				fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		var req T
+		dec := json.NewDecoder(http.MaxBytesReader(sr, r.Body, s.maxRequestBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeError(sr, http.StatusBadRequest,
+				fmt.Errorf("couldn't decode request: %v", err))
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), s.requestTimeout)
+		defer cancel()
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-ctx.Done():
+			writeError(sr, http.StatusServiceUnavailable,
+				fmt.Errorf("too many concurrent requests: %w", ctx.Err()))
+			return
+		}
+		status, resp := endpoint(ctx, &req)
+		writeJSON(sr, status, resp)
+	})
+}
+
+// statusFor returns the HTTP status code corresponding to the given error.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, pwhash.ErrInternal):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// identify handles requests to the identify endpoint.
+func (s *server) identify(_ context.Context, req *identifyRequest) (int,
+	interface{}) {
+	resp := identifyResponse{Candidates: []candidate{}}
+	for _, c := range identify.Identify([]byte(req.Hash), s.functions) {
+		resp.Candidates = append(resp.Candidates, candidate{
+			ID:         c.ID,
+			Name:       c.Name,
+			Hashcat:    c.Hashcat,
+			John:       c.John,
+			Confidence: c.Confidence.String(),
+			Supported:  c.Function != nil,
+		})
+	}
+	return http.StatusOK, resp
+}
+
+// verify handles requests to the verify endpoint.
+func (s *server) verify(ctx context.Context, req *verifyRequest) (int,
+	interface{}) {
+	matches := pwhash.Identify(s.functions, []byte(req.Hash))
+	if len(matches) == 0 {
+		return http.StatusUnprocessableEntity,
+			errorResponse{Error: "no matching hash format"}
+	}
+	// a candidate may match the hash format but fail to parse it fully, such
+	// as a wrapped hash with an invalid inner hash, so skip such candidates
+	// and only fail if none of them could verify the password
+	var parseErr error
+	var verified bool
+	for _, f := range matches {
+		match, err := pwhash.Verify(ctx, f, []byte(req.Hash),
+			[]byte(req.Password), s.opts)
+		if errors.Is(err, pwhash.ErrParse) {
+			parseErr = fmt.Errorf("couldn't verify password using %s: %v", f.ID(),
+				err)
+			continue
+		}
+		if err != nil {
+			return statusFor(err), errorResponse{
+				Error: fmt.Sprintf("couldn't verify password using %s: %v", f.ID(),
+					err)}
+		}
+		if match {
+			return http.StatusOK, verifyResponse{Match: true, Function: f.ID()}
+		}
+		verified = true
+	}
+	if !verified {
+		return statusFor(parseErr), errorResponse{Error: parseErr.Error()}
+	}
+	return http.StatusOK, verifyResponse{Match: false}
+}
+
+// generate handles requests to the generate endpoint.
+func (s *server) generate(ctx context.Context, req *generateRequest) (int,
+	interface{}) {
+	f, ok := s.functions[req.Function]
+	if !ok {
+		return http.StatusUnprocessableEntity,
+			errorResponse{Error: fmt.Sprintf("unknown function %s", req.Function)}
+	}
+	if err := s.opts.CheckCost(f, req.Cost); err != nil {
+		return statusFor(err), errorResponse{Error: err.Error()}
+	}
+	encodedHash, err := pwhash.Generate(ctx, f, []byte(req.Password),
+		&pwhash.GenerateOptions{Cost: req.Cost, Pepper: s.pepper})
+	if err != nil {
+		return statusFor(err), errorResponse{Error: err.Error()}
+	}
+	return http.StatusOK, generateResponse{Hash: encodedHash}
+}
+
+// handler returns the handler for all of the server's endpoints.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/identify", handle(s, s.identify))
+	mux.Handle("/verify", handle(s, s.verify))
+	mux.Handle("/generate", handle(s, s.generate))
+	return mux
+}
+
+// Validate checks the flags after they are parsed by kong.
+func (cmd *ServeCmd) Validate() error {
+	if cmd.MaxRequestBytes <= 0 {
+		return fmt.Errorf("--max-request-bytes must be positive, got %d",
+			cmd.MaxRequestBytes)
+	}
+	if cmd.RequestTimeout <= 0 {
+		return fmt.Errorf("--request-timeout must be positive, got %v",
+			cmd.RequestTimeout)
+	}
+	return nil
+}
+
+// Run the serve command.
+func (cmd *ServeCmd) Run(ctx context.Context,
+	functions map[string]pwhash.Function) error {
+	pepper, err := cmd.pepper()
+	if err != nil {
+		return err
+	}
+	opts := cmd.options()
+	if opts.Peppers, err = cmd.peppers(); err != nil {
+		return err
+	}
+	maxConcurrent := cmd.MaxConcurrent
+	if maxConcurrent < 1 {
+		maxConcurrent = runtime.GOMAXPROCS(0)
+	}
+	s := &server{
+		functions:       functions,
+		opts:            opts,
+		pepper:          pepper,
+		maxRequestBytes: cmd.MaxRequestBytes,
+		requestTimeout:  cmd.RequestTimeout,
+		slots:           make(chan struct{}, maxConcurrent),
+	}
+	srv := &http.Server{
+		Addr:              cmd.Listen,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// shut down the server when the context is done
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(),
+			cmd.RequestTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("couldn't shut down server: %v", err)
+		}
+	}()
+	log.Printf("listening on %s", cmd.Listen)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
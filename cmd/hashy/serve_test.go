@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+)
+
+// unparseableFunction matches md5crypt hashes, but fails to hash them with
+// ErrParse, like a function which can only detect an invalid hash after
+// parsing.
+type unparseableFunction struct {
+	*md5crypt.Function
+}
+
+func (*unparseableFunction) ID() string { return "aaa" }
+
+func (*unparseableFunction) Hash(_, _ []byte, _ uint) ([]byte, error) {
+	return nil, fmt.Errorf("invalid inner hash: %w", pwhash.ErrParse)
+}
+
+// testServer returns a server using the given functions and the defaults of
+// the serve command.
+func testServer(functions map[string]pwhash.Function) *server {
+	return &server{
+		functions:       functions,
+		opts:            &pwhash.VerifyOptions{},
+		maxRequestBytes: 65536,
+		requestTimeout:  10 * time.Second,
+		slots:           make(chan struct{}, runtime.GOMAXPROCS(0)),
+	}
+}
+
+func TestServe(t *testing.T) {
+	var testCases = map[string]struct {
+		functions    map[string]pwhash.Function
+		method       string
+		path         string
+		body         string
+		expectStatus int
+		expectBody   string
+	}{
+		"verify match": {
+			method:       http.MethodPost,
+			path:         "/verify",
+			body:         `{"hash":"$1$28772684$iEwNOgGugqO9.bIz5sk8k/","password":"hashcat"}`,
+			expectStatus: http.StatusOK,
+			expectBody:   `{"match":true,"function":"md5crypt"}`,
+		},
+		"verify no match": {
+			method:       http.MethodPost,
+			path:         "/verify",
+			body:         `{"hash":"$1$28772684$iEwNOgGugqO9.bIz5sk8k/","password":"wrong"}`,
+			expectStatus: http.StatusOK,
+			expectBody:   `{"match":false}`,
+		},
+		"verify skips parse error": {
+			functions: map[string]pwhash.Function{
+				"aaa":      &unparseableFunction{&md5crypt.Function{}},
+				"md5crypt": &md5crypt.Function{},
+			},
+			method:       http.MethodPost,
+			path:         "/verify",
+			body:         `{"hash":"$1$28772684$iEwNOgGugqO9.bIz5sk8k/","password":"hashcat"}`,
+			expectStatus: http.StatusOK,
+			expectBody:   `{"match":true,"function":"md5crypt"}`,
+		},
+		"verify only parse error": {
+			functions: map[string]pwhash.Function{
+				"aaa": &unparseableFunction{&md5crypt.Function{}},
+			},
+			method:       http.MethodPost,
+			path:         "/verify",
+			body:         `{"hash":"$1$28772684$iEwNOgGugqO9.bIz5sk8k/","password":"hashcat"}`,
+			expectStatus: http.StatusUnprocessableEntity,
+			expectBody:   `invalid inner hash`,
+		},
+		"verify max cost": {
+			method:       http.MethodPost,
+			path:         "/verify",
+			body:         `{"hash":"$5$rounds=100000$GX7BopJZJxPc/KEK$B0.otSf8vrLEEGD/pM4aaZMj/gpn3R9yyoAXvNAFQY/","password":"hashcat"}`,
+			expectStatus: http.StatusUnprocessableEntity,
+			expectBody:   `cost exceeds permitted maximum`,
+		},
+		"generate max cost": {
+			method:       http.MethodPost,
+			path:         "/generate",
+			body:         `{"function":"sha256crypt","password":"hashcat","cost":100000}`,
+			expectStatus: http.StatusUnprocessableEntity,
+			expectBody:   `cost exceeds permitted maximum`,
+		},
+		"GET": {
+			method:       http.MethodGet,
+			path:         "/verify",
+			expectStatus: http.StatusMethodNotAllowed,
+			expectBody:   `method GET not allowed`,
+		},
+		"unknown field": {
+			method:       http.MethodPost,
+			path:         "/verify",
+			body:         `{"hash":"$1$28772684$iEwNOgGugqO9.bIz5sk8k/","password":"hashcat","cost":1}`,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `unknown field`,
+		},
+		"body too large": {
+			method: http.MethodPost,
+			path:   "/verify",
+			body: `{"hash":"$1$28772684$iEwNOgGugqO9.bIz5sk8k/","password":"` +
+				strings.Repeat("a", 65536) + `"}`,
+			expectStatus: http.StatusBadRequest,
+			expectBody:   `request body too large`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			functions := tc.functions
+			if functions == nil {
+				functions = registry()
+			}
+			req := httptest.NewRequest(tc.method, tc.path,
+				strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			testServer(functions).handler().ServeHTTP(rec, req)
+			if rec.Code != tc.expectStatus {
+				tt.Fatalf("expected status %d, got %d: %s", tc.expectStatus, rec.Code,
+					rec.Body)
+			}
+			if rec.Code == http.StatusMethodNotAllowed &&
+				rec.Header().Get("Allow") != http.MethodPost {
+				tt.Fatalf("expected Allow header %q, got %q", http.MethodPost,
+					rec.Header().Get("Allow"))
+			}
+			if !strings.Contains(rec.Body.String(), tc.expectBody) {
+				tt.Fatalf("expected body containing %q, got %q", tc.expectBody,
+					rec.Body)
+			}
+		})
+	}
+}
+
+func TestServeLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	password := "correct horse battery staple"
+	s := testServer(registry())
+	for _, r := range []struct{ path, body string }{
+		{"/verify", `{"hash":"$1$28772684$iEwNOgGugqO9.bIz5sk8k/","password":"` +
+			password + `"}`},
+		{"/generate", `{"function":"md5crypt","password":"` + password + `"}`},
+		{"/verify", `{"hash":"invalid","password":"` + password + `"}`},
+		{"/verify", `{"password":"` + password + `","unknown":true}`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, r.path,
+			strings.NewReader(r.body))
+		s.handler().ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if buf.Len() == 0 {
+		t.Fatal("expected requests to be logged")
+	}
+	if strings.Contains(buf.String(), password) {
+		t.Fatalf("password logged: %s", buf.String())
+	}
+}
+
+func TestServeConcurrency(t *testing.T) {
+	s := testServer(registry())
+	s.requestTimeout = 10 * time.Millisecond
+	s.slots = make(chan struct{}, 1)
+	body := `{"hash":"$1$28772684$iEwNOgGugqO9.bIz5sk8k/"}`
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost,
+			"/identify", strings.NewReader(body)))
+		return rec.Code
+	}
+	// occupy the only slot
+	s.slots <- struct{}{}
+	if status := serve(); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable,
+			status)
+	}
+	<-s.slots
+	if status := serve(); status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if len(s.slots) != 0 {
+		t.Fatalf("expected slot to be released, got %d in use", len(s.slots))
+	}
+}
+
+func TestServeFlags(t *testing.T) {
+	var testCases = map[string]struct {
+		args      []string
+		expectErr bool
+	}{
+		"defaults": {},
+		"valid": {
+			args: []string{"--max-request-bytes=1024", "--request-timeout=1s"},
+		},
+		"zero max request bytes": {
+			args:      []string{"--max-request-bytes=0"},
+			expectErr: true,
+		},
+		"negative max request bytes": {
+			args:      []string{"--max-request-bytes=-1"},
+			expectErr: true,
+		},
+		"zero request timeout": {
+			args:      []string{"--request-timeout=0s"},
+			expectErr: true,
+		},
+		"negative request timeout": {
+			args:      []string{"--request-timeout=-1s"},
+			expectErr: true,
+		},
+		"max concurrent": {
+			args: []string{"--max-concurrent=4"},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			parser, err := kong.New(&CLI{})
+			if err != nil {
+				tt.Fatal(err)
+			}
+			_, err = parser.Parse(append([]string{"serve"}, tc.args...))
+			if tc.expectErr && err == nil {
+				tt.Fatal("expected error, got nil")
+			}
+			if !tc.expectErr && err != nil {
+				tt.Fatal(err)
+			}
+		})
+	}
+}
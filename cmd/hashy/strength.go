@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/smlx/hashy/pkg/strength"
+)
+
+// StrengthFlags control the strength check of new passwords.
+type StrengthFlags struct {
+	CheckStrength string `kong:"enum='off,warn,refuse',default='off',help='Estimate the strength of the password, and warn or refuse if it is weaker than --min-strength (${enum})'"`
+	MinStrength   int    `kong:"default='3',help='Minimum strength score of the password, from 0 (very weak) to 4 (very strong)'"`
+}
+
+// Validate checks the flags after they are parsed by kong.
+func (sf *StrengthFlags) Validate() error {
+	if sf.MinStrength < 0 || sf.MinStrength > 4 {
+		return fmt.Errorf("--min-strength must be from 0 to 4, got %d",
+			sf.MinStrength)
+	}
+	return nil
+}
+
+// checkStrength estimates the strength of the given password according to
+// the flags. It prints a warning to stderr, or returns an error if the flags
+// refuse weak passwords.
+func (sf *StrengthFlags) checkStrength(password []byte) error {
+	if sf.CheckStrength == "off" {
+		return nil
+	}
+	r := strength.Estimate(string(password))
+	if int(r.Score) >= sf.MinStrength {
+		return nil
+	}
+	msg := fmt.Sprintf("password is %s (score %d/4)", r.Score, r.Score)
+	if r.Warning != "" {
+		msg += ": " + r.Warning
+	}
+	if sf.CheckStrength == "refuse" {
+		return errors.New(msg)
+	}
+	fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestMinStrength(t *testing.T) {
+	var testCases = map[string]struct {
+		minStrength string
+		expectErr   bool
+	}{
+		"minimum":   {minStrength: "0"},
+		"maximum":   {minStrength: "4"},
+		"negative":  {minStrength: "-1", expectErr: true},
+		"too large": {minStrength: "5", expectErr: true},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			parser, err := kong.New(&CLI{})
+			if err != nil {
+				tt.Fatal(err)
+			}
+			_, err = parser.Parse([]string{"generate", "--function=md5crypt",
+				"--min-strength=" + tc.minStrength, "hashcat"})
+			if tc.expectErr && err == nil {
+				tt.Fatal("expected error, got nil")
+			}
+			if !tc.expectErr && err != nil {
+				tt.Fatal(err)
+			}
+		})
+	}
+}
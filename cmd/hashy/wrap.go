@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/wrapped"
+)
+
+// WrapCmd represents the wrap command.
+type WrapCmd struct {
+	Function    string `kong:"required,enum='sha256crypt,sha512crypt',help='Cryptographic hash function (AKA method) used to wrap the password hash: ${enum}'"`
+	Cost        uint   `kong:"help='CPU time cost of the wrapping function. This parameter has a different meaning for each cryptographic hash function.'"`
+	Inner       string `kong:"help='Function of the hash being wrapped. Required if the hash matches more than one format.'"`
+	EncodedHash string `kong:"required,arg,help='Password hash to wrap, in encoded format'"`
+}
+
+// Run the wrap command.
+func (cmd *WrapCmd) Run(ctx context.Context,
+	functions map[string]pwhash.Function) error {
+	// determine the inner function
+	inner := cmd.Inner
+	if inner == "" {
+		var ids []string
+		for _, f := range pwhash.Identify(functions, []byte(cmd.EncodedHash)) {
+			ids = append(ids, f.ID())
+		}
+		switch len(ids) {
+		case 0:
+			return fmt.Errorf("no matching hash format")
+		case 1:
+			inner = ids[0]
+		default:
+			return fmt.Errorf("hash matches multiple formats (%s): use --inner",
+				strings.Join(ids, ", "))
+		}
+	}
+	id := fmt.Sprintf("%s(%s)", cmd.Function, inner)
+	f, ok := functions[id].(*wrapped.Function)
+	if !ok {
+		return fmt.Errorf("can't wrap %s hash using %s", inner, cmd.Function)
+	}
+	encodedHash, err := f.Wrap(ctx, []byte(cmd.EncodedHash), nil, cmd.Cost)
+	if err != nil {
+		return fmt.Errorf("couldn't wrap hash: %w", err)
+	}
+	_, err = fmt.Println(encodedHash)
+	return err
+}
@@ -2,4 +2,10 @@ module github.com/smlx/hashy
 
 go 1.18
 
-require github.com/alecthomas/kong v0.7.1
+require (
+	github.com/alecthomas/kong v0.7.1
+	golang.org/x/crypto v0.6.0
+	golang.org/x/term v0.5.0
+)
+
+require golang.org/x/sys v0.5.0 // indirect
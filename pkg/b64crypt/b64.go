@@ -1,11 +1,18 @@
 // Package b64crypt implements an encoding similar to RFC4648 Base64, but with
 // a slightly different character set. This encoding is used by Unix crypt() to
 // encode password hashes.
+//
+// Unlike RFC4648 Base64, each group of three bytes is encoded as a 24-bit
+// little-endian integer, least significant six bits first. A trailing group
+// of one or two bytes is right-aligned in the integer, and encoded with the
+// minimum number of characters: two characters for one byte, or three for two
+// bytes. There is no padding.
 package b64crypt
 
 import (
 	"bytes"
 	"crypto/rand"
+	"errors"
 	"fmt"
 )
 
@@ -13,6 +20,33 @@ import (
 const charset = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
 	"abcdefghijklmnopqrstuvwxyz"
 
+var (
+	// ErrCharacter is returned when decoding a character which is not in the
+	// character set of the encoding.
+	ErrCharacter = errors.New("invalid character")
+	// ErrLength is returned when decoding a string with a length that can't be
+	// produced by the encoding.
+	ErrLength = errors.New("invalid length")
+	// ErrNonCanonical is returned when decoding a string which is not the
+	// canonical encoding of its decoded bytes. This happens if the unused bits
+	// of a trailing group are not zero, or if a Permutation encodes a byte
+	// more than once with different values.
+	ErrNonCanonical = errors.New("non-canonical encoding")
+)
+
+// decodeMap maps each character of the encoding to its value, or 0xff if it
+// is not in the character set.
+var decodeMap = func() [256]byte {
+	var m [256]byte
+	for i := range m {
+		m[i] = 0xff
+	}
+	for i := 0; i < len(charset); i++ {
+		m[charset[i]] = byte(i)
+	}
+	return m
+}()
+
 // EncodeBytes encodes a given three bytes to a set of four characters.
 func EncodeBytes(buf *bytes.Buffer, a, b, c uint8) {
 	// calculate the numeric value
@@ -24,6 +58,146 @@ func EncodeBytes(buf *bytes.Buffer, a, b, c uint8) {
 	}
 }
 
+// EncodedLen returns the length of the encoding of n bytes.
+func EncodedLen(n int) int {
+	return (n*8 + 5) / 6
+}
+
+// DecodedLen returns the length of the bytes decoded from an encoding of
+// length n, or -1 if n is not a valid encoded length.
+func DecodedLen(n int) int {
+	if n%4 == 1 {
+		return -1
+	}
+	return n * 6 / 8
+}
+
+// Encode returns the encoding of src. Each group of three bytes in src is
+// encoded in order to four characters, as by EncodeBytes.
+func Encode(src []byte) []byte {
+	dst := make([]byte, 0, EncodedLen(len(src)))
+	for len(src) > 0 {
+		// right-align a trailing partial group
+		var n uint
+		var chars int
+		switch len(src) {
+		case 1:
+			n, chars = uint(src[0]), 2
+			src = src[1:]
+		case 2:
+			n, chars = uint(src[0])<<8|uint(src[1]), 3
+			src = src[2:]
+		default:
+			n, chars = uint(src[0])<<16|uint(src[1])<<8|uint(src[2]), 4
+			src = src[3:]
+		}
+		for i := 0; i < chars; i++ {
+			dst = append(dst, charset[n%64])
+			n >>= 6
+		}
+	}
+	return dst
+}
+
+// Decode returns the bytes decoded from src. It is the inverse of Encode, and
+// is strict: it returns an error wrapping ErrNonCanonical if the unused bits
+// of a trailing group are not zero, so that each decoded value has exactly one
+// encoding.
+func Decode(src []byte) ([]byte, error) {
+	if DecodedLen(len(src)) < 0 {
+		return nil, fmt.Errorf("%d characters: %w", len(src), ErrLength)
+	}
+	dst := make([]byte, 0, DecodedLen(len(src)))
+	for i := 0; i < len(src); i += 4 {
+		group := src[i:]
+		if len(group) > 4 {
+			group = group[:4]
+		}
+		var n uint
+		for j := len(group) - 1; j >= 0; j-- {
+			v := decodeMap[group[j]]
+			if v == 0xff {
+				return nil, fmt.Errorf("%q at offset %d: %w", group[j], i+j,
+					ErrCharacter)
+			}
+			n = n<<6 | uint(v)
+		}
+		switch len(group) {
+		case 2:
+			if n > 0xff {
+				return nil, fmt.Errorf("trailing bits at offset %d: %w", i,
+					ErrNonCanonical)
+			}
+			dst = append(dst, byte(n))
+		case 3:
+			if n > 0xffff {
+				return nil, fmt.Errorf("trailing bits at offset %d: %w", i,
+					ErrNonCanonical)
+			}
+			dst = append(dst, byte(n>>8), byte(n))
+		default:
+			dst = append(dst, byte(n>>16), byte(n>>8), byte(n))
+		}
+	}
+	return dst, nil
+}
+
+// Permutation is a table of byte indexes which determines the order in which
+// the bytes of a digest are encoded. Many crypt() algorithms shuffle the
+// bytes of the digest in this way before encoding it. A Permutation may
+// contain an index more than once, in which case the byte is encoded more
+// than once.
+type Permutation []int
+
+// Len returns the number of bytes encoded by the Permutation. This is one
+// more than the largest index in the table.
+func (p Permutation) Len() int {
+	var n int
+	for _, i := range p {
+		if i >= n {
+			n = i + 1
+		}
+	}
+	return n
+}
+
+// Encode returns the encoding of the bytes of src in the order given by the
+// Permutation. It panics if src is shorter than p.Len().
+func (p Permutation) Encode(src []byte) []byte {
+	permuted := make([]byte, len(p))
+	for i, j := range p {
+		permuted[i] = src[j]
+	}
+	return Encode(permuted)
+}
+
+// Decode returns the bytes decoded from src, restored to their original
+// order. It is the inverse of Encode. In addition to the errors returned by
+// the Decode function, it returns an error wrapping ErrLength if src is not
+// the length of an encoding by the Permutation, and an error wrapping
+// ErrNonCanonical if a byte which is encoded more than once does not have the
+// same value each time.
+func (p Permutation) Decode(src []byte) ([]byte, error) {
+	if len(src) != EncodedLen(len(p)) {
+		return nil, fmt.Errorf("%d characters, expected %d: %w", len(src),
+			EncodedLen(len(p)), ErrLength)
+	}
+	permuted, err := Decode(src)
+	if err != nil {
+		return nil, err
+	}
+	dst := make([]byte, p.Len())
+	seen := make([]bool, len(dst))
+	for i, j := range p {
+		if seen[j] && dst[j] != permuted[i] {
+			return nil, fmt.Errorf("byte %d encoded with different values: %w", j,
+				ErrNonCanonical)
+		}
+		dst[j], seen[j] = permuted[i], true
+	}
+	return dst, nil
+}
+
 // GenerateSalt returns a cryptographically secure random string of length n
 // encoded by EncodeBytes(). n must be divisible by 4 since this is the number
 // of bytes returned by each call to EncodeBytes(). The number of random bytes
@@ -33,15 +207,10 @@ func GenerateSalt(n uint) ([]byte, error) {
 	if n%4 != 0 {
 		return nil, fmt.Errorf("%d is not divisible by 4", n)
 	}
-	randBytesLen := n * 3 / 4
-	rawSalt := make([]byte, randBytesLen)
+	rawSalt := make([]byte, n*3/4)
 	_, err := rand.Read(rawSalt)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't generate random salt: %v", err)
 	}
-	var salt bytes.Buffer
-	for i := uint(0); i < randBytesLen; i += 3 {
-		EncodeBytes(&salt, rawSalt[i], rawSalt[i+1], rawSalt[i+2])
-	}
-	return salt.Bytes(), nil
+	return Encode(rawSalt), nil
 }
@@ -0,0 +1,174 @@
+// Package batch implements verification of many (encoded hash, password)
+// pairs in parallel.
+//
+// Jobs are distributed across a pool of workers, and results are streamed in
+// the order the jobs were received. Each job refers to a hash prepared by
+// pwhash.Prepare, so a hash which is tested against many passwords is only
+// parsed once. Jobs which would calculate the same hash, because they have
+// the same function, salt, cost and password, are only hashed once as long as
+// the calculation is still cached. This is common when auditing many unsalted
+// or identically salted hashes against a wordlist, or checking a password
+// against many hashes generated with a fixed salt.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// Job is a password to verify against a prepared hash.
+type Job struct {
+	// Hash is the prepared hash to verify the password against.
+	Hash *pwhash.Prepared
+	// Password is the candidate password.
+	Password []byte
+	// Tag identifies the job to the caller. It is not used by the Engine.
+	Tag int
+}
+
+// Result is the outcome of a Job.
+type Result struct {
+	// Job is the job which produced this result.
+	Job Job
+	// Match is true if the password matches the hash.
+	Match bool
+	// Err is any error returned by hashing the password. If Err is non-nil,
+	// Match is false.
+	Err error
+}
+
+// DefaultCacheSize is the number of hash calculations retained for
+// deduplication, unless overridden by Engine.CacheSize.
+const DefaultCacheSize = 4096
+
+// Engine verifies jobs using a pool of workers.
+type Engine struct {
+	// Workers is the number of concurrent workers. If it is less than one,
+	// GOMAXPROCS workers are used.
+	Workers int
+	// CacheSize is the number of the most recent hash calculations which are
+	// retained, so that later jobs which would calculate the same hash reuse
+	// the result. If it is less than one, DefaultCacheSize is used.
+	CacheSize int
+}
+
+// calculation is a hash calculation shared by one or more jobs.
+type calculation struct {
+	p    *pwhash.Prepared
+	key  []byte
+	done chan struct{}
+	hash []byte
+	err  error
+}
+
+// task is a received job waiting for its result.
+type task struct {
+	job Job
+	c   *calculation
+	err error
+}
+
+// calculationKey returns a key which is equal for calculations of the same
+// hash.
+func calculationKey(p *pwhash.Prepared, key []byte) string {
+	return fmt.Sprintf("%s\x00%d\x00%q\x00%q", p.Function.ID(), p.Cost, p.Salt,
+		key)
+}
+
+// Run verifies the jobs received from the given channel, and sends the
+// results on the returned channel in the order the jobs were received. The
+// returned channel is closed after the jobs channel is closed and all results
+// have been sent, or ctx is done.
+//
+// If ctx is done, Run stops receiving jobs, and jobs which have been received
+// but not yet hashed have a result with an error wrapping ctx.Err(). The
+// caller must receive all results until the returned channel is closed, and
+// should stop sending jobs when ctx is done.
+func (e *Engine) Run(ctx context.Context, jobs <-chan Job) <-chan Result {
+	workers := e.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	cacheSize := e.CacheSize
+	if cacheSize < 1 {
+		cacheSize = DefaultCacheSize
+	}
+	results := make(chan Result)
+	// pending is the queue of received jobs in order. Its capacity limits
+	// how far workers may run ahead of the receiver of the results.
+	pending := make(chan *task, 4*workers)
+	calculations := make(chan *calculation)
+	// cache maps calculation keys to calculations, and keys holds the keys
+	// in the order they were added so that the oldest can be evicted. Only
+	// the goroutine receiving jobs accesses them.
+	cache := map[string]*calculation{}
+	var keys []string
+	// start the workers
+	for i := 0; i < workers; i++ {
+		go func() {
+			for c := range calculations {
+				c.hash, c.err = pwhash.HashContext(ctx, c.p.Function, c.key, c.p.Salt,
+					c.p.Cost)
+				close(c.done)
+			}
+		}()
+	}
+	// receive jobs and queue them for the workers
+	go func() {
+		defer close(pending)
+		defer close(calculations)
+		for ctx.Err() == nil {
+			var job Job
+			var ok bool
+			select {
+			case job, ok = <-jobs:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+			t := task{job: job}
+			key := job.Hash.Key(job.Password)
+			ckey := calculationKey(job.Hash, key)
+			if t.c = cache[ckey]; t.c == nil {
+				c := &calculation{p: job.Hash, key: key, done: make(chan struct{})}
+				select {
+				case calculations <- c:
+					t.c = c
+					cache[ckey] = c
+					if keys = append(keys, ckey); len(keys) > cacheSize {
+						delete(cache, keys[0])
+						keys = keys[1:]
+					}
+				case <-ctx.Done():
+					t.err = fmt.Errorf("hash cancelled: %w", ctx.Err())
+				}
+			}
+			// the results goroutine receives from pending until it is closed, so
+			// this doesn't block indefinitely as long as the caller receives the
+			// results
+			pending <- &t
+		}
+	}()
+	// send results in order
+	go func() {
+		defer close(results)
+		for t := range pending {
+			r := Result{Job: t.job, Err: t.err}
+			if t.c != nil {
+				<-t.c.done
+				if t.c.err != nil {
+					r.Err = t.c.err
+				} else {
+					r.Match = t.job.Hash.Match(t.c.hash)
+				}
+			}
+			results <- r
+		}
+	}()
+	return results
+}
@@ -0,0 +1,214 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/batch"
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+)
+
+// countingFunction counts the calls to Hash.
+type countingFunction struct {
+	pwhash.Function
+	calls int64
+}
+
+func (f *countingFunction) Hash(key, salt []byte, cost uint) ([]byte, error) {
+	atomic.AddInt64(&f.calls, 1)
+	return f.Function.Hash(key, salt, cost)
+}
+
+// run sends the given jobs to a new Engine and returns the results.
+func run(ctx context.Context, e *batch.Engine, jobs []batch.Job) []batch.Result {
+	c := make(chan batch.Job)
+	go func() {
+		defer close(c)
+		for _, job := range jobs {
+			select {
+			case c <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var results []batch.Result
+	for r := range e.Run(ctx, c) {
+		results = append(results, r)
+	}
+	return results
+}
+
+// prepare returns the given encoded hash prepared using f.
+func prepare(t *testing.T, f pwhash.Function, encodedHash string,
+	opts *pwhash.VerifyOptions) *pwhash.Prepared {
+	t.Helper()
+	p, err := pwhash.Prepare(f, []byte(encodedHash), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRun(t *testing.T) {
+	f := &md5crypt.Function{}
+	pepper := pwhash.Pepper{ID: "1", Key: []byte("key")}
+	peppered, err := pwhash.Generate(context.Background(), f, []byte("hashcat"),
+		&pwhash.GenerateOptions{Pepper: &pepper})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &pwhash.VerifyOptions{Peppers: []pwhash.Pepper{pepper}}
+	var testCases = map[string]struct {
+		encodedHash string
+		password    string
+		expect      bool
+	}{
+		"match": {
+			encodedHash: `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			password:    "hashcat",
+			expect:      true,
+		},
+		"no match": {
+			encodedHash: `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			password:    "notthepassword",
+			expect:      false,
+		},
+		"peppered match": {
+			encodedHash: peppered,
+			password:    "hashcat",
+			expect:      true,
+		},
+		"peppered no match": {
+			encodedHash: peppered,
+			password:    "notthepassword",
+			expect:      false,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			results := run(context.Background(), &batch.Engine{}, []batch.Job{{
+				Hash:     prepare(tt, f, tc.encodedHash, opts),
+				Password: []byte(tc.password),
+			}})
+			if len(results) != 1 {
+				tt.Fatalf("expected 1 result, got %d", len(results))
+			}
+			if results[0].Err != nil {
+				tt.Fatal(results[0].Err)
+			}
+			if results[0].Match != tc.expect {
+				tt.Fatalf("expected %v, got %v", tc.expect, results[0].Match)
+			}
+		})
+	}
+}
+
+func TestRunOrder(t *testing.T) {
+	p := prepare(t, &md5crypt.Function{}, `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+		nil)
+	passwords := []string{"hashcat", "wrong", "hashcat", "also wrong"}
+	var jobs []batch.Job
+	for i := 0; i < 100; i++ {
+		jobs = append(jobs, batch.Job{
+			Hash:     p,
+			Password: []byte(passwords[i%len(passwords)]),
+			Tag:      i,
+		})
+	}
+	results := run(context.Background(), &batch.Engine{Workers: 4}, jobs)
+	if len(results) != len(jobs) {
+		t.Fatalf("expected %d results, got %d", len(jobs), len(results))
+	}
+	for i, r := range results {
+		if r.Job.Tag != i {
+			t.Fatalf("expected result %d to have tag %d, got %d", i, i, r.Job.Tag)
+		}
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		if expect := i%2 == 0; r.Match != expect {
+			t.Fatalf("expected result %d match %v, got %v", i, expect, r.Match)
+		}
+	}
+}
+
+func TestRunDeduplicate(t *testing.T) {
+	f := &countingFunction{Function: &md5crypt.Function{}}
+	// two hashes with the same salt and one with a different salt
+	encodedHashes := []string{
+		`$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+		`$1$28772684$xxxxxxxxxxxxxxxxxxxxxx`,
+		`$1$abcdefgh$xxxxxxxxxxxxxxxxxxxxxx`,
+	}
+	var jobs []batch.Job
+	for _, encodedHash := range encodedHashes {
+		jobs = append(jobs, batch.Job{
+			Hash:     prepare(t, f, encodedHash, nil),
+			Password: []byte("hashcat"),
+		})
+	}
+	results := run(context.Background(), &batch.Engine{Workers: 2}, jobs)
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		if expect := i == 0; r.Match != expect {
+			t.Fatalf("expected result %d match %v, got %v", i, expect, r.Match)
+		}
+	}
+	if calls := atomic.LoadInt64(&f.calls); calls != 2 {
+		t.Fatalf("expected 2 hash calculations, got %d", calls)
+	}
+}
+
+// blockingFunction signals started when Hash is called, and blocks until
+// release is closed.
+type blockingFunction struct {
+	pwhash.Function
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *blockingFunction) Hash(key, salt []byte, cost uint) ([]byte, error) {
+	f.started <- struct{}{}
+	<-f.release
+	return f.Function.Hash(key, salt, cost)
+}
+
+func TestRunCancel(t *testing.T) {
+	f := &blockingFunction{
+		Function: &md5crypt.Function{},
+		started:  make(chan struct{}, 1),
+		release:  make(chan struct{}),
+	}
+	p := prepare(t, f, `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs := make(chan batch.Job)
+	results := (&batch.Engine{Workers: 1}).Run(ctx, jobs)
+	// the first job occupies the only worker
+	jobs <- batch.Job{Hash: p, Password: []byte("hashcat"), Tag: 0}
+	<-f.started
+	// the second job is received, but can't be hashed before cancellation
+	jobs <- batch.Job{Hash: p, Password: []byte("wrong"), Tag: 1}
+	cancel()
+	close(f.release)
+	close(jobs)
+	var rs []batch.Result
+	for r := range results {
+		rs = append(rs, r)
+	}
+	if len(rs) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(rs))
+	}
+	if rs[0].Err != nil || !rs[0].Match {
+		t.Fatalf("expected first job to match, got %v, %v", rs[0].Match, rs[0].Err)
+	}
+	if !errors.Is(rs[1].Err, context.Canceled) {
+		t.Fatalf("expected error %v, got %v", context.Canceled, rs[1].Err)
+	}
+}
@@ -0,0 +1,214 @@
+// Package convert implements conversion of password hashes between the
+// syntax expected by crypt(), hashcat and John the Ripper.
+//
+// Most formats have the same syntax in each tool, but some formats are
+// written with a tool-specific prefix. For example an unsalted MD5 digest is
+// written as bare hex for hashcat, but John the Ripper's canonical form is
+// $dynamic_0$<hex>. Hashes are converted via their native form, which is the
+// form produced by the software that generated the hash.
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/smlx/hashy/pkg/identify"
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// Target is the syntax a hash is converted to.
+type Target int
+
+// Targets.
+const (
+	// Crypt is the native form of the hash, as produced by crypt() or the
+	// software which generated the hash.
+	Crypt Target = iota
+	// Hashcat is the syntax expected by hashcat.
+	Hashcat
+	// John is the syntax expected by John the Ripper.
+	John
+)
+
+// String implements fmt.Stringer.
+func (t Target) String() string {
+	switch t {
+	case Crypt:
+		return "crypt"
+	case Hashcat:
+		return "hashcat"
+	case John:
+		return "john"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTarget returns the Target with the given name.
+func ParseTarget(name string) (Target, error) {
+	for _, t := range []Target{Crypt, Hashcat, John} {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown target %s", name)
+}
+
+var (
+	// ErrUnknownFormat is returned when the format of a hash can't be
+	// identified.
+	ErrUnknownFormat = errors.New("unknown hash format")
+	// ErrUnsupported is returned when a hash format is not supported by the
+	// target.
+	ErrUnsupported = errors.New("hash format not supported by target")
+)
+
+// syntax describes how a format is written for a tool. The tool form of a
+// hash is toolPrefix followed by the native form with nativePrefix removed.
+type syntax struct {
+	id           string
+	target       Target
+	nativePrefix string
+	toolPrefix   string
+}
+
+// syntaxes is the table of formats which are written differently by a tool.
+// Formats which are not in this table have the same syntax in each tool which
+// supports them.
+var syntaxes = []syntax{
+	{id: "mysql41", target: Hashcat, nativePrefix: "*"},
+	{id: "md5(p)", target: John, toolPrefix: "$dynamic_0$"},
+	{id: "sha1(p)", target: John, toolPrefix: "$dynamic_26$"},
+	{id: "md4(p)", target: John, toolPrefix: "$MD4$"},
+	{id: "ntlm", target: John, toolPrefix: "$NT$"},
+	{id: "sha224(p)", target: John, toolPrefix: "$SHA224$"},
+	{id: "sha256(p)", target: John, toolPrefix: "$SHA256$"},
+	{id: "sha384(p)", target: John, toolPrefix: "$SHA384$"},
+	{id: "sha512(p)", target: John, toolPrefix: "$SHA512$"},
+	{id: "django-pbkdf2-sha256", target: John, toolPrefix: "$django$*1*"},
+}
+
+// Hash is a parsed password hash.
+type Hash struct {
+	// User is the username associated with the hash, or empty if there is
+	// none.
+	User string
+	// Format is the format of the hash.
+	Format identify.Candidate
+	// Native is the hash in its native form.
+	Native string
+}
+
+// candidate returns the candidate format of the given native hash with the
+// given ID, or the most likely candidate if id is empty.
+func candidate(native, id string,
+	functions map[string]pwhash.Function) (identify.Candidate, bool) {
+	var best identify.Candidate
+	var found bool
+	for _, c := range identify.Identify([]byte(native), functions) {
+		if c.ID == id {
+			return c, true
+		}
+		if id == "" && (!found || c.Confidence > best.Confidence) {
+			best, found = c, true
+		}
+	}
+	return best, found
+}
+
+// parseHash parses the given hash without a username.
+func parseHash(hash, id string,
+	functions map[string]pwhash.Function) (*Hash, bool) {
+	// try tool-specific syntax first, since it is unambiguous
+	for _, s := range syntaxes {
+		if id != "" && s.id != id {
+			continue
+		}
+		// a syntax with no tool prefix is only tried for an explicit format
+		if (s.toolPrefix == "" && id == "") ||
+			!strings.HasPrefix(hash, s.toolPrefix) {
+			continue
+		}
+		native := s.nativePrefix + strings.TrimPrefix(hash, s.toolPrefix)
+		if c, ok := candidate(native, s.id, functions); ok {
+			return &Hash{Format: c, Native: native}, true
+		}
+	}
+	if c, ok := candidate(hash, id, functions); ok {
+		return &Hash{Format: c, Native: hash}, true
+	}
+	return nil, false
+}
+
+// Parse the given line, which contains a hash in crypt, hashcat or John the
+// Ripper syntax, optionally preceded by a username and colon. If id is not
+// empty the hash is parsed as the format with that ID, otherwise the most
+// likely format is used. If the format is implemented by one of the given
+// functions, the native form is canonicalised by the function.
+func Parse(line, id string, functions map[string]pwhash.Function) (*Hash,
+	error) {
+	h, ok := parseHash(line, id, functions)
+	if !ok {
+		// try again without a username
+		user, hash, found := strings.Cut(line, ":")
+		if found {
+			if h, ok = parseHash(hash, id, functions); ok {
+				h.User = user
+			}
+		}
+	}
+	if !ok {
+		if id != "" {
+			return nil, fmt.Errorf("not a %s hash: %w", id, ErrUnknownFormat)
+		}
+		return nil, ErrUnknownFormat
+	}
+	if f := h.Format.Function; f != nil {
+		hash, salt, cost, err := f.Parse([]byte(h.Native))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse %s hash: %w", f.ID(), err)
+		}
+		h.Native = CryptForm(f, f.Format(hash, salt, cost))
+	}
+	return h, nil
+}
+
+// CryptForm returns the given encoded hash of f in the form produced by
+// crypt(), which omits the rounds parameter of sha256crypt and sha512crypt
+// hashes with the default cost. Other hashes are returned unchanged.
+func CryptForm(f pwhash.Function, encodedHash string) string {
+	// the salt can't contain "$", so this only matches the rounds parameter
+	return strings.Replace(encodedHash,
+		fmt.Sprintf("$rounds=%d$", f.DefaultCost()), "$", 1)
+}
+
+// Convert returns the hash in the syntax of the given target, preceded by
+// the username and a colon if there is a username. It returns an error
+// wrapping ErrUnsupported if the target tool does not support the format of
+// the hash.
+func (h *Hash) Convert(t Target) (string, error) {
+	var hash string
+	switch t {
+	case Crypt:
+		hash = h.Native
+	case Hashcat, John:
+		if (t == Hashcat && h.Format.Hashcat < 0) ||
+			(t == John && h.Format.John == "") {
+			return "", fmt.Errorf("%s: %w", h.Format.ID, ErrUnsupported)
+		}
+		hash = h.Native
+		for _, s := range syntaxes {
+			if s.id == h.Format.ID && s.target == t {
+				hash = s.toolPrefix + strings.TrimPrefix(h.Native, s.nativePrefix)
+				break
+			}
+		}
+	default:
+		return "", fmt.Errorf("unknown target %d", t)
+	}
+	if h.User != "" {
+		return h.User + ":" + hash, nil
+	}
+	return hash, nil
+}
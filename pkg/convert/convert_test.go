@@ -0,0 +1,157 @@
+package convert_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/convert"
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+	"github.com/smlx/hashy/pkg/pwhash/wrapped"
+)
+
+var functions = map[string]pwhash.Function{
+	mariadboldpassword.ID: &mariadboldpassword.Function{},
+	md5crypt.ID:           &md5crypt.Function{},
+	sha256crypt.ID:        &sha256crypt.Function{},
+	"sha256crypt(md5crypt)": wrapped.New(&md5crypt.Function{},
+		&sha256crypt.Function{}),
+}
+
+func TestConvert(t *testing.T) {
+	var testCases = map[string]struct {
+		input   string
+		format  string
+		id      string
+		crypt   string
+		hashcat string
+		john    string
+	}{
+		"md5crypt with user": {
+			input:   `alice:$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			id:      "md5crypt",
+			crypt:   `alice:$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			hashcat: `alice:$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			john:    `alice:$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+		},
+		"sha256crypt canonicalised": {
+			input:   `$5$rounds=5000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			id:      "sha256crypt",
+			crypt:   `$5$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			hashcat: `$5$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			john:    `$5$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+		},
+		"raw md5": {
+			input:   `8743b52063cd84097a65d1633f5c74f5`,
+			id:      "md5(p)",
+			crypt:   `8743b52063cd84097a65d1633f5c74f5`,
+			hashcat: `8743b52063cd84097a65d1633f5c74f5`,
+			john:    `$dynamic_0$8743b52063cd84097a65d1633f5c74f5`,
+		},
+		"raw md5 john syntax": {
+			input:   `bob:$dynamic_0$8743b52063cd84097a65d1633f5c74f5`,
+			id:      "md5(p)",
+			crypt:   `bob:8743b52063cd84097a65d1633f5c74f5`,
+			hashcat: `bob:8743b52063cd84097a65d1633f5c74f5`,
+			john:    `bob:$dynamic_0$8743b52063cd84097a65d1633f5c74f5`,
+		},
+		"ntlm explicit format": {
+			input:   `8743b52063cd84097a65d1633f5c74f5`,
+			format:  "ntlm",
+			id:      "ntlm",
+			crypt:   `8743b52063cd84097a65d1633f5c74f5`,
+			hashcat: `8743b52063cd84097a65d1633f5c74f5`,
+			john:    `$NT$8743b52063cd84097a65d1633f5c74f5`,
+		},
+		"ntlm john syntax": {
+			input:   `$NT$8743b52063cd84097a65d1633f5c74f5`,
+			id:      "ntlm",
+			crypt:   `8743b52063cd84097a65d1633f5c74f5`,
+			hashcat: `8743b52063cd84097a65d1633f5c74f5`,
+			john:    `$NT$8743b52063cd84097a65d1633f5c74f5`,
+		},
+		"mysql41": {
+			input:   `*FCF7C1B8749CF99D88E5F34271D636178FB5D130`,
+			id:      "mysql41",
+			crypt:   `*FCF7C1B8749CF99D88E5F34271D636178FB5D130`,
+			hashcat: `FCF7C1B8749CF99D88E5F34271D636178FB5D130`,
+			john:    `*FCF7C1B8749CF99D88E5F34271D636178FB5D130`,
+		},
+		"mysql41 hashcat syntax": {
+			input:   `FCF7C1B8749CF99D88E5F34271D636178FB5D130`,
+			format:  "mysql41",
+			id:      "mysql41",
+			crypt:   `*FCF7C1B8749CF99D88E5F34271D636178FB5D130`,
+			hashcat: `FCF7C1B8749CF99D88E5F34271D636178FB5D130`,
+			john:    `*FCF7C1B8749CF99D88E5F34271D636178FB5D130`,
+		},
+		"mysql323": {
+			input:   `7196759210defdc0`,
+			id:      "mariaDBOldPassword",
+			crypt:   `7196759210defdc0`,
+			hashcat: `7196759210defdc0`,
+			john:    `7196759210defdc0`,
+		},
+		"django": {
+			input:   `pbkdf2_sha256$20000$H0dPx8NeajVu$GiC4k5kqbbR9qWBlsRgDywNqC2vd9kqfk7zdorEnNas=`,
+			id:      "django-pbkdf2-sha256",
+			crypt:   `pbkdf2_sha256$20000$H0dPx8NeajVu$GiC4k5kqbbR9qWBlsRgDywNqC2vd9kqfk7zdorEnNas=`,
+			hashcat: `pbkdf2_sha256$20000$H0dPx8NeajVu$GiC4k5kqbbR9qWBlsRgDywNqC2vd9kqfk7zdorEnNas=`,
+			john:    `$django$*1*pbkdf2_sha256$20000$H0dPx8NeajVu$GiC4k5kqbbR9qWBlsRgDywNqC2vd9kqfk7zdorEnNas=`,
+		},
+		"ldap ssha": {
+			input:   `{SSHA}AZKja92fbuuB9SpRlHqaoXxbTc43Mzc2MDM1Ng==`,
+			id:      "ldap-ssha",
+			crypt:   `{SSHA}AZKja92fbuuB9SpRlHqaoXxbTc43Mzc2MDM1Ng==`,
+			hashcat: `{SSHA}AZKja92fbuuB9SpRlHqaoXxbTc43Mzc2MDM1Ng==`,
+			john:    `{SSHA}AZKja92fbuuB9SpRlHqaoXxbTc43Mzc2MDM1Ng==`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			h, err := convert.Parse(tc.input, tc.format, functions)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if h.Format.ID != tc.id {
+				tt.Fatalf("expected format %s, got %s", tc.id, h.Format.ID)
+			}
+			for target, expect := range map[convert.Target]string{
+				convert.Crypt:   tc.crypt,
+				convert.Hashcat: tc.hashcat,
+				convert.John:    tc.john,
+			} {
+				result, err := h.Convert(target)
+				if err != nil {
+					tt.Fatalf("couldn't convert to %s: %v", target, err)
+				}
+				if result != expect {
+					tt.Fatalf("expected %s %s, got %s", target, expect, result)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	if _, err := convert.Parse("not a hash", "", functions); !errors.Is(err,
+		convert.ErrUnknownFormat) {
+		t.Fatalf("expected err %v, got %v", convert.ErrUnknownFormat, err)
+	}
+	if _, err := convert.Parse(`$1$28772684$iEwNOgGugqO9.bIz5sk8k/`, "ntlm",
+		functions); !errors.Is(err, convert.ErrUnknownFormat) {
+		t.Fatalf("expected err %v, got %v", convert.ErrUnknownFormat, err)
+	}
+	h, err := convert.Parse(`$wrapped$sha256crypt$md5crypt$0$28772684$`+
+		`$5$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`, "",
+		functions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = h.Convert(convert.Hashcat); !errors.Is(err,
+		convert.ErrUnsupported) {
+		t.Fatalf("expected err %v, got %v", convert.ErrUnsupported, err)
+	}
+}
@@ -0,0 +1,422 @@
+// Package identify implements identification of the format of a password
+// hash. In addition to the formats implemented by pwhash.Function, it
+// recognises bare hex and base64 digests and some other common formats which
+// are not (yet) supported by this module, and maps each format to the
+// corresponding hashcat and John the Ripper modes.
+package identify
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// Confidence is the likelihood that a Candidate is the actual format of a
+// hash.
+type Confidence int
+
+// Confidence levels.
+const (
+	Low Confidence = iota
+	Medium
+	High
+)
+
+// String implements fmt.Stringer.
+func (c Confidence) String() string {
+	switch c {
+	case Low:
+		return "low"
+	case Medium:
+		return "medium"
+	case High:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// Candidate is a possible format of an encoded password hash.
+type Candidate struct {
+	// ID is the unique identification string of the format. If the format is
+	// implemented by a pwhash.Function, this is the ID of that function.
+	ID string
+	// Name is a human-readable description of the format.
+	Name string
+	// Hashcat is the hashcat hash mode (-m), or -1 if the format is not
+	// supported by hashcat.
+	Hashcat int
+	// John is the John the Ripper format name (--format), or empty if the
+	// format is not supported by John the Ripper.
+	John string
+	// Confidence is the likelihood that this is the actual format of the hash.
+	Confidence Confidence
+	// Specificity indicates how unambiguously the hash identifies this format.
+	Specificity pwhash.Specificity
+	// Function implements this format, or is nil if the format is not
+	// supported.
+	Function pwhash.Function
+}
+
+// format is a hash format recognised by this package.
+type format struct {
+	id         string
+	name       string
+	hashcat    int
+	john       string
+	confidence Confidence
+	// specificity is used if there is no pwhash.Function with the same id.
+	specificity pwhash.Specificity
+	// regex matches the encoded form. It is only used if there is no
+	// pwhash.Function with the same id, since Function.Parse is stricter.
+	regex *regexp.Regexp
+}
+
+// regexes shared by several formats.
+var (
+	hex32   = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	hex40   = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+	hex56   = regexp.MustCompile(`^[0-9a-fA-F]{56}$`)
+	hex64   = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+	hex96   = regexp.MustCompile(`^[0-9a-fA-F]{96}$`)
+	hex128  = regexp.MustCompile(`^[0-9a-fA-F]{128}$`)
+	b64md5  = regexp.MustCompile(`^[0-9A-Za-z+/]{22}==$`)
+	b64sha1 = regexp.MustCompile(`^[0-9A-Za-z+/]{27}=$`)
+	b64s256 = regexp.MustCompile(`^[0-9A-Za-z+/]{43}=$`)
+	b64s512 = regexp.MustCompile(`^[0-9A-Za-z+/]{86}==$`)
+	hex32s  = regexp.MustCompile(`^[0-9a-fA-F]{32}:[^\n]{1,256}$`)
+	hex40s  = regexp.MustCompile(`^[0-9a-fA-F]{40}:[^\n]{1,256}$`)
+	hex64s  = regexp.MustCompile(`^[0-9a-fA-F]{64}:[^\n]{1,256}$`)
+	hex96s  = regexp.MustCompile(`^[0-9a-fA-F]{96}:[^\n]{1,256}$`)
+	hex128s = regexp.MustCompile(`^[0-9a-fA-F]{128}:[^\n]{1,256}$`)
+)
+
+// formats is the table of hash formats recognised by this package.
+var formats = []format{
+	// Unix crypt() functions
+	{
+		id: "md5crypt", name: "MD5 crypt", hashcat: 500, john: "md5crypt",
+		confidence:  High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^\$1\$[^$:\n]{1,8}\$[./0-9A-Za-z]{22}$`),
+	},
+	{
+		id: "apr1", name: "Apache MD5 crypt", hashcat: 1600, john: "md5crypt",
+		confidence:  High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^\$apr1\$[^$:\n]{1,8}\$[./0-9A-Za-z]{22}$`),
+	},
+	{
+		id: "sha1crypt", name: "NetBSD SHA1 crypt", hashcat: 15100,
+		john: "sha1crypt", confidence: High,
+		specificity: pwhash.Specific,
+		regex: regexp.MustCompile(
+			`^\$sha1\$[1-9][0-9]*\$[./0-9A-Za-z]{1,64}\$[./0-9A-Za-z]{28}$`),
+	},
+	{
+		id: "sha256crypt", name: "SHA256 crypt", hashcat: 7400,
+		john: "sha256crypt", confidence: High,
+		specificity: pwhash.Specific,
+		regex: regexp.MustCompile(`^\$5\$(?:rounds=[1-9][0-9]+\$)?` +
+			`[^$:\n]{1,16}\$[./0-9A-Za-z]{43}$`),
+	},
+	{
+		id: "sha512crypt", name: "SHA512 crypt", hashcat: 1800,
+		john: "sha512crypt", confidence: High,
+		specificity: pwhash.Specific,
+		regex: regexp.MustCompile(`^\$6\$(?:rounds=[1-9][0-9]+\$)?` +
+			`[^$:\n]{1,16}\$[./0-9A-Za-z]{86}$`),
+	},
+	{
+		id: "bcrypt", name: "bcrypt", hashcat: 3200, john: "bcrypt",
+		confidence:  High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^\$2[abxy]?\$[0-9]{2}\$[./0-9A-Za-z]{53}$`),
+	},
+	{
+		id: "scrypt", name: "scrypt crypt", hashcat: -1, john: "scrypt",
+		confidence:  High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^\$7\$[./0-9A-Za-z]{11,}\$[./0-9A-Za-z]{43}$`),
+	},
+	{
+		id: "yescrypt", name: "yescrypt", hashcat: -1, john: "crypt",
+		confidence:  High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^\$y\$[./0-9A-Za-z]+\$[./0-9A-Za-z]*\$[./0-9A-Za-z]{43}$`),
+	},
+	{
+		id: "descrypt", name: "Traditional DES crypt", hashcat: 1500,
+		john: "descrypt", confidence: Medium,
+		regex: regexp.MustCompile(`^[./0-9A-Za-z]{13}$`),
+	},
+	// other software
+	{
+		id: "mariaDBOldPassword", name: "MariaDB/MySQL OLD_PASSWORD()",
+		hashcat: 200, john: "mysql", confidence: Medium,
+		regex: regexp.MustCompile(`^[0-9a-f]{16}$`),
+	},
+	{
+		id: "mysql41", name: "MariaDB/MySQL PASSWORD()", hashcat: 300,
+		john: "mysql-sha1", confidence: High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^\*[0-9A-F]{40}$`),
+	},
+	{
+		id: "ldap-sha", name: "LDAP {SHA}", hashcat: 101, john: "nsldap",
+		confidence:  High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^\{SHA\}[0-9A-Za-z+/]{27}=$`),
+	},
+	{
+		id: "ldap-ssha", name: "LDAP {SSHA}", hashcat: 111, john: "salted-sha1",
+		confidence:  High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^\{SSHA\}[0-9A-Za-z+/]{32,}={0,2}$`),
+	},
+	{
+		id: "ldap-ssha512", name: "LDAP {SSHA512}", hashcat: 1711,
+		john: "ssha512", confidence: High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^\{SSHA512\}[0-9A-Za-z+/]{91,}={0,2}$`),
+	},
+	{
+		id: "django-pbkdf2-sha256", name: "Django PBKDF2-SHA256",
+		hashcat: 10000, john: "django", confidence: High,
+		specificity: pwhash.Specific,
+		regex: regexp.MustCompile(
+			`^pbkdf2_sha256\$[0-9]+\$[^$]+\$[0-9A-Za-z+/]{43}=$`),
+	},
+	{
+		id: "django-sha1", name: "Django SHA1", hashcat: 124, confidence: High,
+		specificity: pwhash.Specific,
+		regex:       regexp.MustCompile(`^sha1\$[^$]*\$[0-9a-f]{40}$`),
+	},
+	// bare hex digests
+	{
+		id: "md5(p)", name: "MD5", hashcat: 0, john: "raw-md5",
+		confidence: High, regex: hex32,
+	},
+	{
+		id: "ntlm", name: "NTLM", hashcat: 1000, john: "nt",
+		confidence: Medium, regex: hex32,
+	},
+	{
+		id: "md4(p)", name: "MD4", hashcat: 900, john: "raw-md4",
+		confidence: Low, regex: hex32,
+	},
+	{
+		id: "lm", name: "LM", hashcat: 3000, john: "lm",
+		confidence: Low, regex: hex32,
+	},
+	{
+		id: "sha1(p)", name: "SHA1", hashcat: 100, john: "raw-sha1",
+		confidence: High, regex: hex40,
+	},
+	{
+		id: "ripemd160(p)", name: "RIPEMD-160", hashcat: 6000,
+		john: "ripemd-160", confidence: Low, regex: hex40,
+	},
+	{
+		id: "sha224(p)", name: "SHA224", hashcat: 1300, john: "raw-sha224",
+		confidence: High, regex: hex56,
+	},
+	{
+		id: "sha3-224(p)", name: "SHA3-224", hashcat: 17300,
+		confidence: Low, regex: hex56,
+	},
+	{
+		id: "sha256(p)", name: "SHA256", hashcat: 1400, john: "raw-sha256",
+		confidence: High, regex: hex64,
+	},
+	{
+		id: "sha3-256(p)", name: "SHA3-256", hashcat: 17400,
+		confidence: Low, regex: hex64,
+	},
+	{
+		id: "sha384(p)", name: "SHA384", hashcat: 10800, john: "raw-sha384",
+		confidence: High, regex: hex96,
+	},
+	{
+		id: "sha3-384(p)", name: "SHA3-384", hashcat: 17500,
+		confidence: Low, regex: hex96,
+	},
+	{
+		id: "sha512(p)", name: "SHA512", hashcat: 1700, john: "raw-sha512",
+		confidence: High, regex: hex128,
+	},
+	{
+		id: "sha3-512(p)", name: "SHA3-512", hashcat: 17600, john: "raw-sha3",
+		confidence: Low, regex: hex128,
+	},
+	{
+		id: "whirlpool(p)", name: "Whirlpool", hashcat: 6100, john: "whirlpool",
+		confidence: Low, regex: hex128,
+	},
+	// salted hex digests in hashcat hash:salt syntax
+	{
+		id: "md5(p.s)", name: "md5($pass.$salt)", hashcat: 10,
+		confidence: Medium, regex: hex32s,
+	},
+	{
+		id: "md5(s.p)", name: "md5($salt.$pass)", hashcat: 20,
+		confidence: Medium, regex: hex32s,
+	},
+	{
+		id: "md5(s.p.s)", name: "md5($salt.$pass.$salt)", hashcat: 3800,
+		confidence: Medium, regex: hex32s,
+	},
+	{
+		id: "sha1(p.s)", name: "sha1($pass.$salt)", hashcat: 110,
+		confidence: Medium, regex: hex40s,
+	},
+	{
+		id: "sha1(s.p)", name: "sha1($salt.$pass)", hashcat: 120,
+		confidence: Medium, regex: hex40s,
+	},
+	{
+		id: "sha1(s.p.s)", name: "sha1($salt.$pass.$salt)", hashcat: 4900,
+		confidence: Medium, regex: hex40s,
+	},
+	{
+		id: "sha256(p.s)", name: "sha256($pass.$salt)", hashcat: 1410,
+		confidence: Medium, regex: hex64s,
+	},
+	{
+		id: "sha256(s.p)", name: "sha256($salt.$pass)", hashcat: 1420,
+		confidence: Medium, regex: hex64s,
+	},
+	{
+		id: "sha384(p.s)", name: "sha384($pass.$salt)", hashcat: 10810,
+		confidence: Medium, regex: hex96s,
+	},
+	{
+		id: "sha384(s.p)", name: "sha384($salt.$pass)", hashcat: 10820,
+		confidence: Medium, regex: hex96s,
+	},
+	{
+		id: "sha512(p.s)", name: "sha512($pass.$salt)", hashcat: 1710,
+		confidence: Medium, regex: hex128s,
+	},
+	{
+		id: "sha512(s.p)", name: "sha512($salt.$pass)", hashcat: 1720,
+		confidence: Medium, regex: hex128s,
+	},
+	// salted HMACs in hashcat hash:salt syntax
+	{
+		id: "hmac-md5(p,s)", name: "HMAC-MD5 (key = $pass)", hashcat: 50,
+		confidence: Medium, regex: hex32s,
+	},
+	{
+		id: "hmac-md5(s,p)", name: "HMAC-MD5 (key = $salt)", hashcat: 60,
+		confidence: Medium, regex: hex32s,
+	},
+	{
+		id: "hmac-sha1(p,s)", name: "HMAC-SHA1 (key = $pass)", hashcat: 150,
+		confidence: Medium, regex: hex40s,
+	},
+	{
+		id: "hmac-sha1(s,p)", name: "HMAC-SHA1 (key = $salt)", hashcat: 160,
+		confidence: Medium, regex: hex40s,
+	},
+	{
+		id: "hmac-sha256(p,s)", name: "HMAC-SHA256 (key = $pass)", hashcat: 1450,
+		confidence: Medium, regex: hex64s,
+	},
+	{
+		id: "hmac-sha256(s,p)", name: "HMAC-SHA256 (key = $salt)", hashcat: 1460,
+		confidence: Medium, regex: hex64s,
+	},
+	{
+		id: "hmac-sha512(p,s)", name: "HMAC-SHA512 (key = $pass)", hashcat: 1750,
+		confidence: Medium, regex: hex128s,
+	},
+	{
+		id: "hmac-sha512(s,p)", name: "HMAC-SHA512 (key = $salt)", hashcat: 1760,
+		confidence: Medium, regex: hex128s,
+	},
+	// bare base64 digests
+	{
+		id: "base64(md5(p))", name: "MD5 (base64)", hashcat: -1,
+		confidence: Medium, regex: b64md5,
+	},
+	{
+		id: "base64(sha1(p))", name: "SHA1 (base64)", hashcat: -1,
+		confidence: Medium, regex: b64sha1,
+	},
+	{
+		id: "base64(sha256(p))", name: "SHA256 (base64)", hashcat: -1,
+		confidence: Medium, regex: b64s256,
+	},
+	{
+		id: "base64(sha512(p))", name: "SHA512 (base64)", hashcat: -1,
+		confidence: Medium, regex: b64s512,
+	},
+}
+
+// Identify returns the candidate formats of the given encoded hash, ordered
+// by descending specificity and then by ID, in the same way as
+// pwhash.Identify. If a format is implemented by one of the given functions,
+// it is only returned if the function can parse the hash. Functions which are not in the table of known formats are returned if
+// they can parse the hash, with high confidence if their encoded form is
+// pwhash.Specific and medium confidence otherwise.
+func Identify(encodedHash []byte,
+	functions map[string]pwhash.Function) []Candidate {
+	// parse the hash once with each function
+	parsed := map[string]pwhash.Function{}
+	for _, f := range pwhash.Identify(functions, encodedHash) {
+		parsed[f.ID()] = f
+	}
+	var candidates []Candidate
+	known := map[string]bool{}
+	for _, hf := range formats {
+		known[hf.id] = true
+		f := parsed[hf.id]
+		if _, ok := functions[hf.id]; ok {
+			if f == nil {
+				continue
+			}
+		} else if !hf.regex.Match(encodedHash) {
+			continue
+		}
+		specificity := hf.specificity
+		if f != nil {
+			specificity = pwhash.SpecificityOf(f)
+		}
+		candidates = append(candidates, Candidate{
+			ID:          hf.id,
+			Name:        hf.name,
+			Hashcat:     hf.hashcat,
+			John:        hf.john,
+			Confidence:  hf.confidence,
+			Specificity: specificity,
+			Function:    f,
+		})
+	}
+	for id, f := range parsed {
+		if known[id] {
+			continue
+		}
+		specificity := pwhash.SpecificityOf(f)
+		confidence := Medium
+		if specificity == pwhash.Specific {
+			confidence = High
+		}
+		candidates = append(candidates, Candidate{
+			ID:          id,
+			Name:        id,
+			Hashcat:     -1,
+			Confidence:  confidence,
+			Specificity: specificity,
+			Function:    f,
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Specificity != candidates[j].Specificity {
+			return candidates[i].Specificity > candidates[j].Specificity
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+	return candidates
+}
@@ -0,0 +1,100 @@
+package identify_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/identify"
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/rawdigest"
+	"github.com/smlx/hashy/pkg/pwhash/sha512crypt"
+)
+
+func TestIdentify(t *testing.T) {
+	functions := map[string]pwhash.Function{
+		mariadboldpassword.ID: &mariadboldpassword.Function{},
+		md5crypt.ID:           &md5crypt.Function{},
+		sha512crypt.ID:        &sha512crypt.Function{},
+	}
+	for _, f := range []pwhash.Function{
+		rawdigest.New(rawdigest.SHA1, rawdigest.Prefix, rawdigest.Hex),
+		rawdigest.New(rawdigest.SHA3_256, rawdigest.Prefix, rawdigest.Hex),
+	} {
+		functions[f.ID()] = f
+	}
+	var testCases = map[string]struct {
+		input  string
+		expect []string
+	}{
+		"md5crypt": {
+			input:  `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			expect: []string{"md5crypt"},
+		},
+		"bcrypt": {
+			input:  `$2a$05$LhayLxezLhK1LhWvKxCyLOj0j1u.Kj0jZ0pEmm134uzrQlFvQJLF6`,
+			expect: []string{"bcrypt"},
+		},
+		"hex md5": {
+			input:  `8743b52063cd84097a65d1633f5c74f5`,
+			expect: []string{"lm", "md4(p)", "md5(p)", "ntlm"},
+		},
+		"hex sha1": {
+			input:  `b89eaac7e61417341b710b727768294d0e6a277b`,
+			expect: []string{"ripemd160(p)", "sha1(p)"},
+		},
+		"salted hex md5": {
+			input: `e0dc808d4120602679e730d130c0126e:28772684`,
+			expect: []string{"hmac-md5(p,s)", "hmac-md5(s,p)", "md5(p.s)",
+				"md5(s.p)", "md5(s.p.s)"},
+		},
+		"salted hex sha1": {
+			input: `b89eaac7e61417341b710b727768294d0e6a277b:28772684`,
+			expect: []string{"hmac-sha1(p,s)", "hmac-sha1(s,p)", "sha1(p.s)",
+				"sha1(s.p)", "sha1(s.p.s)"},
+		},
+		"salted hex sha3-256": {
+			input: `ad3fd5c1a7bfd0e4b9b0d15bd3d1b33dbd1bf4a1bbbc6e0ad3d2cfe8e2ab9e67:` +
+				`28772684`,
+			expect: []string{"hmac-sha256(p,s)", "hmac-sha256(s,p)",
+				"sha256(p.s)", "sha256(s.p)", "sha3-256(s.p)"},
+		},
+		"mysql323": {
+			input:  `7196759210defdc0`,
+			expect: []string{"mariaDBOldPassword"},
+		},
+		"unknown": {
+			input:  `not a hash`,
+			expect: nil,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			var ids []string
+			for _, c := range identify.Identify([]byte(tc.input), functions) {
+				ids = append(ids, c.ID)
+			}
+			if !reflect.DeepEqual(tc.expect, ids) {
+				tt.Fatalf("expected %v, got %v", tc.expect, ids)
+			}
+		})
+	}
+}
+
+func TestIdentifyFunction(t *testing.T) {
+	functions := map[string]pwhash.Function{
+		md5crypt.ID: &md5crypt.Function{},
+	}
+	candidates := identify.Identify(
+		[]byte(`$1$28772684$iEwNOgGugqO9.bIz5sk8k/`), functions)
+	if len(candidates) != 1 {
+		t.Fatalf("expected 1 candidate, got %d", len(candidates))
+	}
+	c := candidates[0]
+	if c.Hashcat != 500 || c.John != "md5crypt" ||
+		c.Confidence != identify.High || c.Specificity != pwhash.Specific ||
+		c.Function != functions[md5crypt.ID] {
+		t.Fatalf("unexpected candidate %+v", c)
+	}
+}
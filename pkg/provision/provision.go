@@ -0,0 +1,124 @@
+// Package provision implements formatting of password hashes for
+// provisioning tools, with the escaping required by each tool.
+package provision
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Template is an output format for a password hash.
+type Template int
+
+// Templates.
+const (
+	// Hash is the bare encoded hash.
+	Hash Template = iota
+	// Chpasswd is a user:hash line for chpasswd -e.
+	Chpasswd
+	// Usermod is a usermod -p shell command.
+	Usermod
+	// CloudInit is a cloud-init users entry.
+	CloudInit
+	// Ansible is an ansible.builtin.user task.
+	Ansible
+)
+
+// String implements fmt.Stringer.
+func (t Template) String() string {
+	switch t {
+	case Hash:
+		return "hash"
+	case Chpasswd:
+		return "chpasswd"
+	case Usermod:
+		return "usermod"
+	case CloudInit:
+		return "cloud-init"
+	case Ansible:
+		return "ansible"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTemplate returns the Template with the given name.
+func ParseTemplate(name string) (Template, error) {
+	for _, t := range []Template{Hash, Chpasswd, Usermod, CloudInit, Ansible} {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown template %s", name)
+}
+
+var (
+	// ErrUser is returned when a template requires a username which is
+	// missing or can't be represented.
+	ErrUser = errors.New("invalid username")
+	// ErrHash is returned when a hash can't be represented in a template.
+	ErrHash = errors.New("hash not supported by template")
+)
+
+// shellQuote returns s quoted for a POSIX shell. Single quotes prevent
+// expansion of the $ characters in crypt hashes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// yamlQuote returns s as a single-quoted YAML scalar, in which $ and other
+// characters have no special meaning.
+func yamlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// ansibleQuote returns s as a YAML scalar which Ansible will not template. It
+// is tagged !unsafe if it contains characters which could start a Jinja2
+// expression.
+func ansibleQuote(s string) string {
+	if strings.ContainsAny(s, "{}") {
+		return "!unsafe " + yamlQuote(s)
+	}
+	return yamlQuote(s)
+}
+
+// Format returns the given encoded hash of the given user's password in the
+// given template. All templates except Hash require a user.
+func Format(t Template, user, hash string) (string, error) {
+	if strings.ContainsAny(hash, "\r\n") {
+		return "", fmt.Errorf("hash contains newline: %w", ErrHash)
+	}
+	if t != Hash {
+		if user == "" {
+			return "", fmt.Errorf("%s template requires a username: %w", t, ErrUser)
+		}
+		if strings.ContainsAny(user, ":\r\n") {
+			return "", fmt.Errorf("username contains ':' or newline: %w", ErrUser)
+		}
+	}
+	switch t {
+	case Hash:
+		return hash, nil
+	case Chpasswd:
+		// chpasswd splits the line at the first colon, so a hash may contain a
+		// colon, but it must be a single line
+		return fmt.Sprintf("%s:%s", user, hash), nil
+	case Usermod:
+		// end the options so that a username starting with "-" is not parsed as
+		// an option
+		return fmt.Sprintf("usermod -p %s -- %s", shellQuote(hash),
+			shellQuote(user)), nil
+	case CloudInit:
+		return fmt.Sprintf("users:\n  - name: %s\n    passwd: %s",
+			yamlQuote(user), yamlQuote(hash)), nil
+	case Ansible:
+		// the task name, username and hash are all templated by Ansible
+		return fmt.Sprintf("- name: %s\n"+
+			"  ansible.builtin.user:\n    name: %s\n    password: %s",
+			ansibleQuote("Set password of "+user), ansibleQuote(user),
+			ansibleQuote(hash)), nil
+	default:
+		return "", fmt.Errorf("unknown template %d", t)
+	}
+}
@@ -0,0 +1,131 @@
+package provision_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/provision"
+)
+
+const sha512Hash = `$6$zrr5Kt7jpmLAHTeX$aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`
+
+func TestFormat(t *testing.T) {
+	var testCases = map[string]struct {
+		template  provision.Template
+		user      string
+		hash      string
+		expect    string
+		expectErr error
+	}{
+		"hash": {
+			template: provision.Hash,
+			hash:     sha512Hash,
+			expect:   sha512Hash,
+		},
+		"chpasswd": {
+			template: provision.Chpasswd,
+			user:     "alice",
+			hash:     sha512Hash,
+			expect:   "alice:" + sha512Hash,
+		},
+		"usermod": {
+			template: provision.Usermod,
+			user:     "alice",
+			hash:     sha512Hash,
+			expect:   "usermod -p '" + sha512Hash + "' -- 'alice'",
+		},
+		"usermod quote": {
+			template: provision.Usermod,
+			user:     "o'brien",
+			hash:     "$1$it's$x",
+			expect:   `usermod -p '$1$it'\''s$x' -- 'o'\''brien'`,
+		},
+		"usermod option user": {
+			template: provision.Usermod,
+			user:     "-G wheel",
+			hash:     sha512Hash,
+			expect:   "usermod -p '" + sha512Hash + "' -- '-G wheel'",
+		},
+		"cloud-init": {
+			template: provision.CloudInit,
+			user:     "alice",
+			hash:     sha512Hash,
+			expect:   "users:\n  - name: 'alice'\n    passwd: '" + sha512Hash + "'",
+		},
+		"cloud-init quote": {
+			template: provision.CloudInit,
+			user:     "o'brien",
+			hash:     "$1$it's$x",
+			expect:   "users:\n  - name: 'o''brien'\n    passwd: '$1$it''s$x'",
+		},
+		"ansible": {
+			template: provision.Ansible,
+			user:     "alice",
+			hash:     sha512Hash,
+			expect: "- name: 'Set password of alice'\n" +
+				"  ansible.builtin.user:\n    name: 'alice'\n" +
+				"    password: '" + sha512Hash + "'",
+		},
+		"ansible jinja": {
+			template: provision.Ansible,
+			user:     "alice",
+			hash:     "{{x}}",
+			expect: "- name: 'Set password of alice'\n" +
+				"  ansible.builtin.user:\n    name: 'alice'\n" +
+				"    password: !unsafe '{{x}}'",
+		},
+		"ansible jinja user": {
+			template: provision.Ansible,
+			user:     "{{ lookup('env', 'HOME') }}",
+			hash:     sha512Hash,
+			expect: "- name: !unsafe 'Set password of {{ lookup(''env'', ''HOME'') }}'\n" +
+				"  ansible.builtin.user:\n" +
+				"    name: !unsafe '{{ lookup(''env'', ''HOME'') }}'\n" +
+				"    password: '" + sha512Hash + "'",
+		},
+		"missing user": {
+			template:  provision.Chpasswd,
+			hash:      sha512Hash,
+			expectErr: provision.ErrUser,
+		},
+		"colon in user": {
+			template:  provision.Chpasswd,
+			user:      "a:b",
+			hash:      sha512Hash,
+			expectErr: provision.ErrUser,
+		},
+		"newline in hash": {
+			template:  provision.Hash,
+			hash:      "a\nb",
+			expectErr: provision.ErrHash,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			out, err := provision.Format(tc.template, tc.user, tc.hash)
+			if !errors.Is(err, tc.expectErr) {
+				tt.Fatalf("expected err %v, got %v", tc.expectErr, err)
+			}
+			if out != tc.expect {
+				tt.Fatalf("expected\n%s\ngot\n%s", tc.expect, out)
+			}
+		})
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	for _, tmpl := range []provision.Template{provision.Hash,
+		provision.Chpasswd, provision.Usermod, provision.CloudInit,
+		provision.Ansible} {
+		parsed, err := provision.ParseTemplate(tmpl.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != tmpl {
+			t.Fatalf("expected %v, got %v", tmpl, parsed)
+		}
+	}
+	if _, err := provision.ParseTemplate("nope"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,142 @@
+// Package pwgen implements generation of cryptographically random passwords
+// and diceware passphrases.
+package pwgen
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Character sets for random passwords.
+const (
+	// Digits are the decimal digits.
+	Digits = "0123456789"
+	// Hex are the lower case hexadecimal digits.
+	Hex = "0123456789abcdef"
+	// Alpha are the upper and lower case ASCII letters.
+	Alpha = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// Alnum are the upper and lower case ASCII letters and decimal digits.
+	Alnum = Alpha + Digits
+	// ASCII are the printable ASCII characters, excluding space.
+	ASCII = Alnum + "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+)
+
+// ErrCharset is returned when a character set is invalid.
+var ErrCharset = errors.New("invalid character set")
+
+// Charset returns the character set with the given name: digits, hex, alpha,
+// alnum or ascii. Any other name is interpreted as a literal set of
+// characters, which must contain at least two distinct characters.
+func Charset(name string) (string, error) {
+	switch name {
+	case "digits":
+		return Digits, nil
+	case "hex":
+		return Hex, nil
+	case "alpha":
+		return Alpha, nil
+	case "alnum":
+		return Alnum, nil
+	case "ascii":
+		return ASCII, nil
+	}
+	// deduplicate the literal set, so each character is equally likely
+	var b strings.Builder
+	seen := map[rune]bool{}
+	for _, r := range name {
+		if !seen[r] {
+			seen[r] = true
+			b.WriteRune(r)
+		}
+	}
+	if len(seen) < 2 {
+		return "", fmt.Errorf("%q has fewer than two distinct characters: %w",
+			name, ErrCharset)
+	}
+	return b.String(), nil
+}
+
+// randomIndex returns a uniformly random integer in [0, n).
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("couldn't generate random number: %v", err)
+	}
+	return int(i.Int64()), nil
+}
+
+// Random returns a password of the given length in runes, with each rune
+// chosen uniformly at random from the given character set.
+func Random(length int, charset string) (string, error) {
+	if length < 1 {
+		return "", fmt.Errorf("invalid length %d", length)
+	}
+	chars := []rune(charset)
+	if len(chars) < 2 {
+		return "", fmt.Errorf("fewer than two characters: %w", ErrCharset)
+	}
+	password := make([]rune, length)
+	for i := range password {
+		idx, err := randomIndex(len(chars))
+		if err != nil {
+			return "", err
+		}
+		password[i] = chars[idx]
+	}
+	return string(password), nil
+}
+
+// RandomBits returns the entropy in bits of a password generated by Random
+// with the given length and character set, assuming the characters of the
+// set are distinct.
+func RandomBits(length int, charset string) float64 {
+	return float64(length) * math.Log2(float64(len([]rune(charset))))
+}
+
+//go:embed eff_large_wordlist.txt
+var wordlistFile string
+
+// wordlist is the EFF large wordlist, in order of dice rolls.
+var wordlist = parseWordlist(wordlistFile)
+
+// parseWordlist returns the words of the given wordlist file, ignoring the
+// dice rolls, empty lines and comments.
+func parseWordlist(file string) []string {
+	var words []string
+	for _, line := range strings.Split(file, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		_, word, _ := strings.Cut(line, "\t")
+		words = append(words, word)
+	}
+	return words
+}
+
+// Diceware returns a passphrase of the given number of words, each chosen
+// uniformly at random from the EFF large wordlist, joined by separator.
+func Diceware(words int, separator string) (string, error) {
+	if words < 1 {
+		return "", fmt.Errorf("invalid number of words %d", words)
+	}
+	chosen := make([]string, words)
+	for i := range chosen {
+		idx, err := randomIndex(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+		chosen[i] = wordlist[idx]
+	}
+	return strings.Join(chosen, separator), nil
+}
+
+// DicewareBits returns the entropy in bits of a passphrase generated by
+// Diceware with the given number of words.
+func DicewareBits(words int) float64 {
+	return float64(words) * math.Log2(float64(len(wordlist)))
+}
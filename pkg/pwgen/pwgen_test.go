@@ -0,0 +1,102 @@
+package pwgen_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwgen"
+)
+
+func TestCharset(t *testing.T) {
+	var testCases = map[string]struct {
+		name      string
+		expect    string
+		expectErr error
+	}{
+		"named":     {name: "hex", expect: pwgen.Hex},
+		"literal":   {name: "abc", expect: "abc"},
+		"duplicate": {name: "abcabc", expect: "abc"},
+		"unicode":   {name: "äöü", expect: "äöü"},
+		"single":    {name: "aaaa", expectErr: pwgen.ErrCharset},
+		"empty":     {name: "", expectErr: pwgen.ErrCharset},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			charset, err := pwgen.Charset(tc.name)
+			if !errors.Is(err, tc.expectErr) {
+				tt.Fatalf("expected err %v, got %v", tc.expectErr, err)
+			}
+			if charset != tc.expect {
+				tt.Fatalf("expected %q, got %q", tc.expect, charset)
+			}
+		})
+	}
+}
+
+func TestRandom(t *testing.T) {
+	var testCases = map[string]struct {
+		length  int
+		charset string
+	}{
+		"alnum":   {length: 20, charset: pwgen.Alnum},
+		"ascii":   {length: 32, charset: pwgen.ASCII},
+		"unicode": {length: 8, charset: "äöü"},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			password, err := pwgen.Random(tc.length, tc.charset)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if n := len([]rune(password)); n != tc.length {
+				tt.Fatalf("expected length %d, got %d", tc.length, n)
+			}
+			for _, r := range password {
+				if !strings.ContainsRune(tc.charset, r) {
+					tt.Fatalf("unexpected character %q in %q", r, password)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomDistribution(t *testing.T) {
+	// every character of a small set should appear in a long password
+	password, err := pwgen.Random(1000, pwgen.Hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range pwgen.Hex {
+		if !strings.ContainsRune(password, r) {
+			t.Fatalf("character %q not generated", r)
+		}
+	}
+}
+
+func TestDiceware(t *testing.T) {
+	passphrase, err := pwgen.Diceware(6, " ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Split(passphrase, " ")
+	if len(words) != 6 {
+		t.Fatalf("expected 6 words, got %q", passphrase)
+	}
+	for _, w := range words {
+		if w == "" {
+			t.Fatalf("empty word in %q", passphrase)
+		}
+	}
+}
+
+func TestBits(t *testing.T) {
+	// the EFF large wordlist has 6^5 words
+	if bits := pwgen.DicewareBits(1); math.Abs(bits-5*math.Log2(6)) > 1e-9 {
+		t.Fatalf("expected %v bits per word, got %v", 5*math.Log2(6), bits)
+	}
+	if bits := pwgen.RandomBits(10, pwgen.Hex); bits != 40 {
+		t.Fatalf("expected 40 bits, got %v", bits)
+	}
+}
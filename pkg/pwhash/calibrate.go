@@ -0,0 +1,84 @@
+package pwhash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// calibrationMinDuration is the minimum duration of a measurement used by
+// Calibrate, to reduce the effect of timer resolution and setup overhead.
+const calibrationMinDuration = 20 * time.Millisecond
+
+// ErrTarget is returned by Calibrate when the target duration is invalid.
+var ErrTarget = errors.New("invalid target duration")
+
+// The VariableCost interface is implemented by Functions which support a
+// variable cost.
+type VariableCost interface {
+	// CostRange returns the minimum and maximum cost values accepted by Hash.
+	CostRange() (min, max uint)
+}
+
+// Calibrate measures the given function on the current machine and returns
+// the cost at which Hash takes approximately the target duration. The time
+// taken by Hash is assumed to be proportional to the cost. The result is
+// clamped to the range of costs accepted by the function. An error wrapping
+// ErrCost is returned if the function does not implement VariableCost, and
+// ErrTarget if the target duration is not positive.
+func Calibrate(ctx context.Context, f Function, target time.Duration) (uint,
+	error) {
+	if target <= 0 {
+		return 0, fmt.Errorf("%v: %w", target, ErrTarget)
+	}
+	vc, ok := f.(VariableCost)
+	if !ok {
+		return 0, fmt.Errorf("%s does not support a variable cost: %w", f.ID(),
+			ErrCost)
+	}
+	costMin, costMax := vc.CostRange()
+	salt, err := f.GenerateSalt()
+	if err != nil {
+		return 0, fmt.Errorf("couldn't generate salt: %v", err)
+	}
+	key := []byte("calibration")
+	// double the cost, and then the number of hashes once the maximum cost is
+	// reached, until the measurement is long enough to be meaningful
+	var elapsed time.Duration
+	cost, hashes := f.DefaultCost(), uint(1)
+	if cost < 1 {
+		cost = 1
+	}
+	for {
+		start := time.Now()
+		for i := uint(0); i < hashes; i++ {
+			if _, err = HashContext(ctx, f, key, salt, cost); err != nil {
+				return 0, fmt.Errorf("couldn't hash using %s: %w", f.ID(), err)
+			}
+		}
+		elapsed = time.Since(start)
+		if elapsed >= calibrationMinDuration {
+			break
+		}
+		if cost < costMax {
+			cost *= 2
+			if cost > costMax {
+				cost = costMax
+			}
+		} else {
+			hashes *= 2
+		}
+	}
+	// scale the measured cost to the target duration
+	estimate := float64(cost) * float64(hashes) * float64(target) /
+		float64(elapsed)
+	switch {
+	case estimate < float64(costMin):
+		return costMin, nil
+	case estimate > float64(costMax):
+		return costMax, nil
+	default:
+		return uint(estimate), nil
+	}
+}
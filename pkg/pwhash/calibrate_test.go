@@ -0,0 +1,75 @@
+package pwhash_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+)
+
+func TestCalibrate(t *testing.T) {
+	var testCases = map[string]struct {
+		function pwhash.Function
+		target   time.Duration
+		expect   error
+	}{
+		"variable cost": {
+			function: &sha256crypt.Function{},
+			target:   50 * time.Millisecond,
+			expect:   nil,
+		},
+		"tiny target": {
+			function: &sha256crypt.Function{},
+			target:   time.Nanosecond,
+			expect:   nil,
+		},
+		"zero target": {
+			function: &sha256crypt.Function{},
+			target:   0,
+			expect:   pwhash.ErrTarget,
+		},
+		"negative target": {
+			function: &sha256crypt.Function{},
+			target:   -time.Second,
+			expect:   pwhash.ErrTarget,
+		},
+		"fixed cost": {
+			function: &mariadboldpassword.Function{},
+			target:   50 * time.Millisecond,
+			expect:   pwhash.ErrCost,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			cost, err := pwhash.Calibrate(context.Background(), tc.function,
+				tc.target)
+			if !errors.Is(err, tc.expect) {
+				tt.Fatalf("expected err %v, got %v", tc.expect, err)
+			}
+			if err != nil {
+				return
+			}
+			costMin, costMax := tc.function.(pwhash.VariableCost).CostRange()
+			if cost < costMin || cost > costMax {
+				tt.Fatalf("cost %d outside range %d-%d", cost, costMin, costMax)
+			}
+			if _, err = tc.function.Hash([]byte("foo"), []byte("salt"),
+				cost); err != nil {
+				tt.Fatalf("couldn't hash with calibrated cost: %v", err)
+			}
+		})
+	}
+}
+
+func TestCalibrateCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := pwhash.Calibrate(ctx, &sha256crypt.Function{}, time.Second)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected err %v, got %v", context.Canceled, err)
+	}
+}
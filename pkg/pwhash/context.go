@@ -0,0 +1,29 @@
+package pwhash
+
+import (
+	"context"
+	"fmt"
+)
+
+// The ContextHasher interface is implemented by Functions which can abandon a
+// long-running hash calculation when a context is cancelled.
+type ContextHasher interface {
+	// HashContext is like Hash, but returns an error wrapping ctx.Err() if ctx
+	// is done before the hash is calculated.
+	HashContext(ctx context.Context, key, salt []byte, cost uint) ([]byte,
+		error)
+}
+
+// HashContext returns the hash of the given key using f. If f implements
+// ContextHasher the calculation is abandoned when ctx is done, otherwise ctx
+// is only checked before f.Hash is called.
+func HashContext(ctx context.Context, f Function, key, salt []byte,
+	cost uint) ([]byte, error) {
+	if ch, ok := f.(ContextHasher); ok {
+		return ch.HashContext(ctx, key, salt, cost)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("hash cancelled: %w", err)
+	}
+	return f.Hash(key, salt, cost)
+}
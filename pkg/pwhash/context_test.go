@@ -0,0 +1,51 @@
+package pwhash_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+)
+
+func TestHashContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	var testCases = map[string]struct {
+		ctx      context.Context
+		function pwhash.Function
+		expect   error
+	}{
+		"ContextHasher": {
+			ctx:      context.Background(),
+			function: &sha256crypt.Function{},
+			expect:   nil,
+		},
+		"ContextHasher cancelled": {
+			ctx:      cancelled,
+			function: &sha256crypt.Function{},
+			expect:   context.Canceled,
+		},
+		"Function": {
+			ctx:      context.Background(),
+			function: &mariadboldpassword.Function{},
+			expect:   nil,
+		},
+		"Function cancelled": {
+			ctx:      cancelled,
+			function: &mariadboldpassword.Function{},
+			expect:   context.Canceled,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			_, err := pwhash.HashContext(tc.ctx, tc.function, []byte("foo"), nil,
+				tc.function.DefaultCost())
+			if !errors.Is(err, tc.expect) {
+				tt.Fatalf("expected err %v, got %v", tc.expect, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package pwhash
+
+// The Digester interface is implemented by Functions which can decode the
+// hash returned by Hash and Parse to the raw digest bytes. This allows hashes
+// to be compared and converted to other encodings.
+type Digester interface {
+	// Digest returns the raw digest bytes encoded in the given hash. It returns
+	// an error wrapping ErrParse if the hash is not the canonical encoding of a
+	// digest.
+	Digest(hash []byte) ([]byte, error)
+}
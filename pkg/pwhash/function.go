@@ -5,6 +5,9 @@ import "errors"
 var (
 	// ErrSaltLen is returned when a salt of invalid length is passed.
 	ErrSaltLen = errors.New("invalid salt length")
+	// ErrSaltCharset is returned when a salt containing invalid characters is
+	// passed.
+	ErrSaltCharset = errors.New("invalid salt character")
 	// ErrKeyLen is returned when a key of invalid length is passed.
 	ErrKeyLen = errors.New("invalid key length")
 	// ErrParse is returned when an encoded hash doesn't match the expected format.
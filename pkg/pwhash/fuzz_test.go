@@ -0,0 +1,192 @@
+package pwhash_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/hmac"
+	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/rawdigest"
+	"github.com/smlx/hashy/pkg/pwhash/sha1crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha512crypt"
+	"github.com/smlx/hashy/pkg/pwhash/wrapped"
+)
+
+// fuzzCostSpan limits the cost of fuzzed hashes to this many above the
+// minimum cost of the function, so that each input is hashed quickly.
+const fuzzCostSpan = 1000
+
+// fuzzParse checks that fn.Parse either returns an error wrapping ErrParse or
+// ErrCost, or returns values which survive a round-trip through fn.Format and
+// fn.Parse unchanged.
+func fuzzParse(f *testing.F, fn pwhash.Function, seeds ...string) {
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, encodedHash []byte) {
+		hash, salt, cost, err := fn.Parse(encodedHash)
+		if err != nil {
+			if !errors.Is(err, pwhash.ErrParse) && !errors.Is(err, pwhash.ErrCost) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return
+		}
+		formatted := fn.Format(hash, salt, cost)
+		hash2, salt2, cost2, err := fn.Parse([]byte(formatted))
+		if err != nil {
+			t.Fatalf("couldn't parse formatted hash %s: %v", formatted, err)
+		}
+		if !bytes.Equal(hash, hash2) || !bytes.Equal(salt, salt2) || cost != cost2 {
+			t.Fatalf("round-trip of %s through %s changed parsed values",
+				encodedHash, formatted)
+		}
+	})
+}
+
+// fuzzHash checks that fn.Hash either returns an error wrapping one of the
+// pwhash errors, or returns a deterministic result which survives a
+// round-trip through fn.Format and fn.Parse if the salt is valid.
+func fuzzHash(f *testing.F, fn pwhash.Function, seedSalt string) {
+	f.Add([]byte("hashcat"), []byte(seedSalt), fn.DefaultCost())
+	f.Add([]byte(""), []byte(seedSalt), uint(0))
+	f.Fuzz(func(t *testing.T, key, salt []byte, cost uint) {
+		_, variableCost := fn.(pwhash.VariableCost)
+		if variableCost {
+			minCost, maxCost := fn.(pwhash.VariableCost).CostRange()
+			if cost >= minCost && cost <= maxCost {
+				cost = minCost + cost%fuzzCostSpan
+			}
+		}
+		result, err := fn.Hash(key, salt, cost)
+		if err != nil {
+			for _, e := range []error{pwhash.ErrKeyLen, pwhash.ErrSaltLen,
+				pwhash.ErrSaltCharset, pwhash.ErrCost} {
+				if errors.Is(err, e) {
+					return
+				}
+			}
+			t.Fatalf("unexpected error: %v", err)
+		}
+		again, err := fn.Hash(key, salt, cost)
+		if err != nil {
+			t.Fatalf("couldn't hash again: %v", err)
+		}
+		if !bytes.Equal(result, again) {
+			t.Fatalf("hash is not deterministic: %s != %s", result, again)
+		}
+		// some functions have no encoded form for the empty hash
+		if len(result) == 0 || pwhash.ValidateSalt(fn, salt) != nil {
+			return
+		}
+		formatted := fn.Format(result, salt, cost)
+		hash, parsedSalt, parsedCost, err := fn.Parse([]byte(formatted))
+		if err != nil {
+			t.Fatalf("couldn't parse formatted hash %s: %v", formatted, err)
+		}
+		if !bytes.Equal(result, hash) || !bytes.Equal(salt, parsedSalt) ||
+			(variableCost && cost != parsedCost) {
+			t.Fatalf("round-trip through %s changed hash values", formatted)
+		}
+	})
+}
+
+func FuzzParseMD5crypt(f *testing.F) {
+	fuzzParse(f, &md5crypt.Function{}, `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`)
+}
+
+func FuzzHashMD5crypt(f *testing.F) {
+	fuzzHash(f, &md5crypt.Function{}, "28772684")
+}
+
+func FuzzParseSHA1crypt(f *testing.F) {
+	fuzzParse(f, &sha1crypt.Function{},
+		`$sha1$20000$75552156$HhYMDdaEHiK3eMIzTldOFPnw.s2Q`,
+		`$sha1$2$TXWp0ay.aLe6o9bBbRLuxpM96L8OkI$ZNh796bK7UZ82dMG8IqVo2LbaE5o`)
+}
+
+func FuzzHashSHA1crypt(f *testing.F) {
+	fuzzHash(f, &sha1crypt.Function{}, "75552156")
+}
+
+func FuzzParseSHA256crypt(f *testing.F) {
+	fuzzParse(f, &sha256crypt.Function{},
+		`$5$rounds=5000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+		`$5$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`)
+}
+
+func FuzzHashSHA256crypt(f *testing.F) {
+	fuzzHash(f, &sha256crypt.Function{}, "GX7BopJZJxPc/KEK")
+}
+
+func FuzzParseSHA512crypt(f *testing.F) {
+	fuzzParse(f, &sha512crypt.Function{},
+		`$6$zrr5Kt7jpmLAHTeX$aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`,
+		`$6$rounds=10000$zrr5Kt7jpmLAHTeX$aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`)
+}
+
+func FuzzHashSHA512crypt(f *testing.F) {
+	fuzzHash(f, &sha512crypt.Function{}, "zrr5Kt7jpmLAHTeX")
+}
+
+func FuzzParseMariaDBOldPassword(f *testing.F) {
+	fuzzParse(f, &mariadboldpassword.Function{}, `7196759210defdc0`)
+}
+
+func FuzzHashMariaDBOldPassword(f *testing.F) {
+	fuzzHash(f, &mariadboldpassword.Function{}, "")
+}
+
+func FuzzParseWrapped(f *testing.F) {
+	fuzzParse(f, wrapped.New(&md5crypt.Function{}, &sha512crypt.Function{}),
+		`$wrapped$sha512crypt$md5crypt$0$28772684$`+
+			`$6$rounds=10000$zrr5Kt7jpmLAHTeX$aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`)
+}
+
+func FuzzHashWrapped(f *testing.F) {
+	fuzzHash(f, wrapped.New(&md5crypt.Function{}, &sha512crypt.Function{}),
+		"0$28772684$zrr5Kt7jpmLAHTeX")
+}
+
+func FuzzParseRawDigest(f *testing.F) {
+	fuzzParse(f, rawdigest.New(rawdigest.MD5, rawdigest.Prefix, rawdigest.Base64),
+		`4NyAjUEgYCZ55zDRMMASbg==:28772684`)
+}
+
+func FuzzHashRawDigest(f *testing.F) {
+	fuzzHash(f, rawdigest.New(rawdigest.SHA256, rawdigest.Both, rawdigest.Hex),
+		"28772684")
+}
+
+func FuzzParseHMACSecret(f *testing.F) {
+	fuzzParse(f, hmac.New(rawdigest.MD5, hmac.Secret, []byte("secret key")),
+		`70733cbe9580ed746004e947f038cf05`)
+}
+
+func FuzzHashHMACSecret(f *testing.F) {
+	fuzzHash(f, hmac.New(rawdigest.SHA256, hmac.Secret, []byte("secret key")),
+		"")
+}
+
+func FuzzParseHMACSalt(f *testing.F) {
+	fuzzParse(f, hmac.New(rawdigest.MD5, hmac.Salt, nil),
+		`313dbfce029b4cdac7bd53496784ad6f:28772684`,
+		`af589d0a3c0abcdfd9ed5daaf72464b9:salt:with:colons`)
+}
+
+func FuzzHashHMACSalt(f *testing.F) {
+	fuzzHash(f, hmac.New(rawdigest.SHA256, hmac.Salt, nil), "28772684")
+}
+
+func FuzzParseHMACPassword(f *testing.F) {
+	fuzzParse(f, hmac.New(rawdigest.MD5, hmac.Password, nil),
+		`deb68082e6d4541675749821444934ad:28772684`,
+		`0dc1344ee71e70092b5e881bb3af5e23:salt:with:colons`)
+}
+
+func FuzzHashHMACPassword(f *testing.F) {
+	fuzzHash(f, hmac.New(rawdigest.SHA256, hmac.Password, nil), "28772684")
+}
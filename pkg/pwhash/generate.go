@@ -0,0 +1,79 @@
+package pwhash
+
+import (
+	"context"
+	"fmt"
+)
+
+// GenerateOptions control the generation of a password hash.
+type GenerateOptions struct {
+	// Salt is used instead of a generated salt if it is not nil. It is
+	// validated by the function.
+	Salt []byte
+	// Cost is used instead of the default cost of the function if it is not
+	// zero.
+	Cost uint
+	// Pepper is applied to the password before it is hashed if it is not nil,
+	// and its ID is recorded in the encoded hash.
+	Pepper *Pepper
+}
+
+// The SaltValidator interface is implemented by Functions which can check a
+// caller-supplied salt before it is used.
+type SaltValidator interface {
+	// ValidateSalt returns an error wrapping ErrSaltLen or ErrSaltCharset if
+	// the given salt cannot be used by this function.
+	ValidateSalt(salt []byte) error
+}
+
+// ValidateSalt returns an error wrapping ErrSaltLen or ErrSaltCharset if the
+// given salt cannot be used by f. If f does not implement SaltValidator the
+// salt is not checked, and is only validated when it is passed to f.Hash.
+func ValidateSalt(f Function, salt []byte) error {
+	if sv, ok := f.(SaltValidator); ok {
+		return sv.ValidateSalt(salt)
+	}
+	return nil
+}
+
+// Generate returns the hash of the given password using f, in encoded form.
+// If opts is nil the defaults are used.
+func Generate(ctx context.Context, f Function, password []byte,
+	opts *GenerateOptions) (string, error) {
+	if opts == nil {
+		opts = &GenerateOptions{}
+	}
+	var err error
+	// get a salt
+	salt := opts.Salt
+	if salt == nil {
+		salt, err = f.GenerateSalt()
+		if err != nil {
+			return "", fmt.Errorf("couldn't generate salt: %v", err)
+		}
+	} else if err = ValidateSalt(f, salt); err != nil {
+		return "", fmt.Errorf("invalid salt: %w", err)
+	}
+	// use the default cost if none was passed
+	cost := opts.Cost
+	if cost == 0 {
+		cost = f.DefaultCost()
+	}
+	// apply the pepper
+	if opts.Pepper != nil {
+		if err = opts.Pepper.validate(); err != nil {
+			return "", err
+		}
+		password = opts.Pepper.Apply(password)
+	}
+	// generate a hash
+	hash, err := HashContext(ctx, f, password, salt, cost)
+	if err != nil {
+		return "", fmt.Errorf("couldn't hash password: %w", err)
+	}
+	encodedHash := f.Format(hash, salt, cost)
+	if opts.Pepper != nil {
+		return formatPepper(opts.Pepper.ID, encodedHash), nil
+	}
+	return encodedHash, nil
+}
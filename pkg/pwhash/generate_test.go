@@ -0,0 +1,108 @@
+package pwhash_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+)
+
+// minimalFunction implements only the methods of pwhash.Function, like an
+// implementation outside this module might.
+type minimalFunction struct {
+	pwhash.Function
+}
+
+func TestGenerate(t *testing.T) {
+	var testCases = map[string]struct {
+		function  pwhash.Function
+		opts      *pwhash.GenerateOptions
+		expect    string
+		expectErr error
+	}{
+		"defaults": {
+			function: &md5crypt.Function{},
+		},
+		"salt": {
+			function: &md5crypt.Function{},
+			opts:     &pwhash.GenerateOptions{Salt: []byte("28772684")},
+			expect:   `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+		},
+		"invalid salt": {
+			function:  &md5crypt.Function{},
+			opts:      &pwhash.GenerateOptions{Salt: []byte("287726841")},
+			expectErr: pwhash.ErrSaltLen,
+		},
+		"no salt validator": {
+			function: minimalFunction{&md5crypt.Function{}},
+			opts:     &pwhash.GenerateOptions{Salt: []byte("28772684")},
+			expect:   `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+		},
+		"no salt validator invalid salt": {
+			function:  minimalFunction{&md5crypt.Function{}},
+			opts:      &pwhash.GenerateOptions{Salt: []byte("287726841")},
+			expectErr: pwhash.ErrSaltLen,
+		},
+		"cost": {
+			function: &sha256crypt.Function{},
+			opts: &pwhash.GenerateOptions{
+				Salt: []byte("GX7BopJZJxPc/KEK"),
+				Cost: 10000,
+			},
+			expect: `$5$rounds=10000$GX7BopJZJxPc/KEK$`,
+		},
+		"pepper": {
+			function: &md5crypt.Function{},
+			opts: &pwhash.GenerateOptions{
+				Pepper: &pwhash.Pepper{ID: "2023-01", Key: []byte("key")},
+			},
+			expect: `$pepper$2023-01$$1$`,
+		},
+		"invalid pepper ID": {
+			function: &md5crypt.Function{},
+			opts: &pwhash.GenerateOptions{
+				Pepper: &pwhash.Pepper{ID: "a$b", Key: []byte("key")},
+			},
+			expectErr: pwhash.ErrPepper,
+		},
+		"empty pepper key": {
+			function: &md5crypt.Function{},
+			opts: &pwhash.GenerateOptions{
+				Pepper: &pwhash.Pepper{ID: "1"},
+			},
+			expectErr: pwhash.ErrPepper,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			encodedHash, err := pwhash.Generate(context.Background(), tc.function,
+				[]byte("hashcat"), tc.opts)
+			if !errors.Is(err, tc.expectErr) {
+				tt.Fatalf("expected err %v, got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !strings.HasPrefix(encodedHash, tc.expect) {
+				tt.Fatalf("expected prefix %s, got %s", tc.expect, encodedHash)
+			}
+			// check that the generated hash verifies
+			var verifyOpts pwhash.VerifyOptions
+			if tc.opts != nil && tc.opts.Pepper != nil {
+				verifyOpts.Peppers = []pwhash.Pepper{*tc.opts.Pepper}
+			}
+			match, err := pwhash.Verify(context.Background(), tc.function,
+				[]byte(encodedHash), []byte("hashcat"), &verifyOpts)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if !match {
+				tt.Fatalf("password doesn't match generated hash %s", encodedHash)
+			}
+		})
+	}
+}
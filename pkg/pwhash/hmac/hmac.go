@@ -0,0 +1,241 @@
+// Package hmac implements a family of pwhash.Functions which calculate an
+// HMAC of the password, as used by some homegrown applications. Each function
+// is parametrised by the inner digest algorithm and the source of the HMAC
+// key:
+//
+//   - Secret: the key is a server-side secret which is not stored in the
+//     hash, and the message is the password. For example hmac-sha256(k,p).
+//     The encoded form of a hash is the hex digest.
+//   - Salt: the key is the salt, and the message is the password. For example
+//     hmac-sha256(s,p).
+//   - Password: the key is the password, and the message is the salt. For
+//     example hmac-sha256(p,s).
+//
+// The encoded form of a salted hash is the hex digest followed by a colon
+// and the salt, as used by hashcat:
+//
+//	<digest>:<salt>
+//
+// These functions are not suitable for storing passwords, since they are
+// very fast to calculate. They are implemented so that existing hashes can be
+// identified and checked.
+package hmac
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"github.com/smlx/hashy/pkg/b64crypt"
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/rawdigest"
+)
+
+const (
+	// saltMaxLen is an arbitrary limit on the salt length
+	saltMaxLen = 256
+	// saltGenLen is the length of generated salts
+	saltGenLen = 16
+	// keyMaxLen sets an arbitrary 32K limit to avoid DoS
+	keyMaxLen = 1 << 15
+)
+
+// KeySource is the source of the HMAC key.
+type KeySource int
+
+// Key sources.
+const (
+	// Secret uses a server-side secret as the key, and the password as the
+	// message.
+	Secret KeySource = iota
+	// Salt uses the salt as the key, and the password as the message.
+	Salt
+	// Password uses the password as the key, and the salt as the message.
+	Password
+)
+
+// String returns the key and message in the notation used by the function
+// ID.
+func (s KeySource) String() string {
+	switch s {
+	case Secret:
+		return "k,p"
+	case Salt:
+		return "s,p"
+	case Password:
+		return "p,s"
+	default:
+		return "unknown"
+	}
+}
+
+// Function implements the pwhash.Function interface for a digest algorithm
+// and key source.
+type Function struct {
+	alg        rawdigest.Algorithm
+	src        KeySource
+	secret     []byte
+	parseRegex *regexp.Regexp
+}
+
+// New returns a Function which calculates the HMAC using the given
+// algorithm and key source. The secret is the HMAC key if src is Secret, and
+// is ignored otherwise.
+func New(alg rawdigest.Algorithm, src KeySource, secret []byte) *Function {
+	hashRegex := fmt.Sprintf(`(?P<hash>[0-9a-fA-F]{%d})`,
+		hex.EncodedLen(alg.New().Size()))
+	if src == Secret {
+		return &Function{
+			alg:        alg,
+			src:        src,
+			secret:     secret,
+			parseRegex: regexp.MustCompile(`^` + hashRegex + `$`),
+		}
+	}
+	return &Function{
+		alg: alg,
+		src: src,
+		parseRegex: regexp.MustCompile(fmt.Sprintf(`^%s:(?P<salt>[^\n]{1,%d})$`,
+			hashRegex, saltMaxLen)),
+	}
+}
+
+// Functions returns a Function for each combination of the algorithms
+// supported by the rawdigest package and the Salt and Password key sources.
+func Functions() []*Function {
+	var functions []*Function
+	for _, alg := range rawdigest.Algorithms() {
+		for _, src := range []KeySource{Salt, Password} {
+			functions = append(functions, New(alg, src, nil))
+		}
+	}
+	return functions
+}
+
+// SecretFunctions returns a Function for each of the algorithms supported by
+// the rawdigest package, keyed by the given server-side secret.
+func SecretFunctions(secret []byte) []*Function {
+	var functions []*Function
+	for _, alg := range rawdigest.Algorithms() {
+		functions = append(functions, New(alg, Secret, secret))
+	}
+	return functions
+}
+
+// Hash returns the hex encoded HMAC of the given key and salt. The cost
+// argument is ignored, since these functions have no such parameter.
+func (f *Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
+	// perform some safety checks
+	if len(key) > keyMaxLen {
+		return nil, fmt.Errorf("key longer than %d bytes: %w", keyMaxLen,
+			pwhash.ErrKeyLen)
+	}
+	if err := f.ValidateSalt(salt); err != nil {
+		return nil, err
+	}
+	var hmacKey, message []byte
+	switch f.src {
+	case Secret:
+		hmacKey, message = f.secret, key
+	case Salt:
+		hmacKey, message = salt, key
+	case Password:
+		hmacKey, message = key, salt
+	}
+	h := hmac.New(f.alg.New, hmacKey)
+	// h.Write never returns an error
+	h.Write(message)
+	sum := h.Sum(nil)
+	encoded := make([]byte, hex.EncodedLen(len(sum)))
+	hex.Encode(encoded, sum)
+	return encoded, nil
+}
+
+// Parse the given hash string in its common encoded form. The digest is
+// converted to lower case.
+func (f *Function) Parse(encodedHash []byte) ([]byte, []byte, uint, error) {
+	matches := f.parseRegex.FindSubmatch(encodedHash)
+	if len(matches) < 2 {
+		return nil, nil, 0, fmt.Errorf("couldn't parse %s format: %w", f.ID(),
+			pwhash.ErrParse)
+	}
+	hash := bytes.ToLower(matches[f.parseRegex.SubexpIndex("hash")])
+	if f.src == Secret {
+		return hash, nil, 0, nil
+	}
+	return hash, matches[f.parseRegex.SubexpIndex("salt")], 0, nil
+}
+
+// Format the given parameters into the common "password hash" form. The
+// cost parameter is not used by these functions.
+func (f *Function) Format(hash, salt []byte, cost uint) string {
+	if f.src == Secret {
+		return string(hash)
+	}
+	return fmt.Sprintf("%s:%s", hash, salt)
+}
+
+// Digest returns the raw digest bytes encoded in the given hash.
+func (f *Function) Digest(hash []byte) ([]byte, error) {
+	digest, err := hex.DecodeString(string(hash))
+	if err != nil || len(digest) != f.alg.New().Size() {
+		return nil, fmt.Errorf("couldn't decode %s hash: %w", f.ID(),
+			pwhash.ErrParse)
+	}
+	return digest, nil
+}
+
+// ID returns the unique identification string of this hash function, such as
+// hmac-sha256(k,p).
+func (f *Function) ID() string {
+	return fmt.Sprintf("hmac-%s(%s)", f.alg.Name(), f.src)
+}
+
+// DefaultCost returns zero, since these functions have no cost parameter.
+func (*Function) DefaultCost() uint {
+	return 0
+}
+
+// GenerateSalt returns a cryptographically secure salt value, or nil if the
+// function uses a server-side secret key.
+func (f *Function) GenerateSalt() ([]byte, error) {
+	if f.src == Secret {
+		return nil, nil
+	}
+	return b64crypt.GenerateSalt(saltGenLen)
+}
+
+// ValidateSalt checks that the given salt is of valid length and contains no
+// newline, which can't be represented in the encoded form. Functions which
+// use a server-side secret key do not accept a salt.
+func (f *Function) ValidateSalt(salt []byte) error {
+	if f.src == Secret {
+		if len(salt) > 0 {
+			return fmt.Errorf("%s does not use a salt: %w", f.ID(),
+				pwhash.ErrSaltLen)
+		}
+		return nil
+	}
+	if len(salt) < 1 || len(salt) > saltMaxLen {
+		return fmt.Errorf("salt must be 1 to %d bytes: %w", saltMaxLen,
+			pwhash.ErrSaltLen)
+	}
+	if bytes.ContainsRune(salt, '\n') {
+		return fmt.Errorf("salt contains newline: %w", pwhash.ErrSaltCharset)
+	}
+	return nil
+}
+
+// Verdict returns a weak verdict, since a single HMAC is too fast to
+// calculate.
+func (*Function) Verdict() pwhash.Verdict {
+	return pwhash.Verdict{
+		Weak:   true,
+		Reason: "a single fast HMAC, which is unsuitable for storing passwords",
+		References: []string{
+			"https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#password-hashing-algorithms",
+		},
+	}
+}
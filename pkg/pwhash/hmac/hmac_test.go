@@ -0,0 +1,116 @@
+package hmac_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/hmac"
+	"github.com/smlx/hashy/pkg/pwhash/pwhashtest"
+	"github.com/smlx/hashy/pkg/pwhash/rawdigest"
+)
+
+// testSecret is the secret key used to generate the test vectors.
+var testSecret = []byte("secret key")
+
+func functions() []*hmac.Function {
+	return append(hmac.Functions(), hmac.SecretFunctions(testSecret)...)
+}
+
+func TestVectors(t *testing.T) {
+	for _, f := range functions() {
+		t.Run(f.ID(), func(tt *testing.T) {
+			pwhashtest.TestVectors(tt, f, "testdata/vectors.jsonl")
+		})
+	}
+}
+
+func TestFunction(t *testing.T) {
+	for _, f := range functions() {
+		t.Run(f.ID(), func(tt *testing.T) {
+			pwhashtest.TestFunction(tt, f)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	// https://en.wikipedia.org/wiki/HMAC#Examples
+	var testCases = map[string]struct {
+		function    *hmac.Function
+		encodedHash string
+		expect      bool
+		expectErr   error
+	}{
+		"match": {
+			function: hmac.New(rawdigest.SHA256, hmac.Secret, []byte("key")),
+			encodedHash: "f7bc83f430538424b13298e6aa6fb143" +
+				"ef4d59a14946175997479dbc2d1a3cd8",
+			expect: true,
+		},
+		"wrong secret": {
+			function: hmac.New(rawdigest.SHA256, hmac.Secret, []byte("not key")),
+			encodedHash: "f7bc83f430538424b13298e6aa6fb143" +
+				"ef4d59a14946175997479dbc2d1a3cd8",
+			expect: false,
+		},
+		"upper case": {
+			function: hmac.New(rawdigest.SHA256, hmac.Secret, []byte("key")),
+			encodedHash: "F7BC83F430538424B13298E6AA6FB143" +
+				"EF4D59A14946175997479DBC2D1A3CD8",
+			expect: true,
+		},
+		"salt as key": {
+			function: hmac.New(rawdigest.SHA256, hmac.Salt, nil),
+			encodedHash: "f7bc83f430538424b13298e6aa6fb143" +
+				"ef4d59a14946175997479dbc2d1a3cd8:key",
+			expect: true,
+		},
+		"unexpected salt": {
+			function: hmac.New(rawdigest.SHA256, hmac.Secret, []byte("key")),
+			encodedHash: "f7bc83f430538424b13298e6aa6fb143" +
+				"ef4d59a14946175997479dbc2d1a3cd8:key",
+			expectErr: pwhash.ErrParse,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			match, err := pwhash.Verify(context.Background(), tc.function,
+				[]byte(tc.encodedHash),
+				[]byte("The quick brown fox jumps over the lazy dog"), nil)
+			if !errors.Is(err, tc.expectErr) {
+				tt.Fatalf("expected err %v, got %v", tc.expectErr, err)
+			}
+			if match != tc.expect {
+				tt.Fatalf("expected match %v, got %v", tc.expect, match)
+			}
+		})
+	}
+}
+
+func TestID(t *testing.T) {
+	var testCases = map[string]struct {
+		function *hmac.Function
+		expect   string
+	}{
+		"secret": {
+			function: hmac.New(rawdigest.SHA256, hmac.Secret, testSecret),
+			expect:   "hmac-sha256(k,p)",
+		},
+		"salt": {
+			function: hmac.New(rawdigest.SHA3_512, hmac.Salt, nil),
+			expect:   "hmac-sha3-512(s,p)",
+		},
+		"password": {
+			function: hmac.New(rawdigest.MD5, hmac.Password, nil),
+			expect:   "hmac-md5(p,s)",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			if id := tc.function.ID(); id != tc.expect {
+				tt.Fatalf("expected %s, got %s", tc.expect, id)
+			}
+		})
+	}
+}
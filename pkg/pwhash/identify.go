@@ -0,0 +1,53 @@
+package pwhash
+
+import "sort"
+
+// Specificity indicates how unambiguously the encoded form of a hash
+// identifies the function which produced it.
+type Specificity int
+
+const (
+	// Unspecific encoded forms, such as bare hex digests, may be produced by
+	// many different functions.
+	Unspecific Specificity = iota
+	// Specific encoded forms contain an identifying prefix, such as the "$1$"
+	// of md5crypt.
+	Specific
+)
+
+// The Specifier interface may be implemented by a Function to indicate the
+// specificity of its encoded form. Functions which do not implement Specifier
+// are considered Unspecific.
+type Specifier interface {
+	// Specificity returns the specificity of the encoded form of the function.
+	Specificity() Specificity
+}
+
+// SpecificityOf returns the specificity of the given function.
+func SpecificityOf(f Function) Specificity {
+	if s, ok := f.(Specifier); ok {
+		return s.Specificity()
+	}
+	return Unspecific
+}
+
+// Identify returns the functions which can parse the given encoded hash,
+// ignoring any pepper prefix. The result is deterministic: functions are
+// ordered by descending specificity, and then by ID.
+func Identify(functions map[string]Function, encodedHash []byte) []Function {
+	encodedHash = stripPepper(encodedHash)
+	var matches []Function
+	for _, f := range functions {
+		if _, _, _, err := f.Parse(encodedHash); err == nil {
+			matches = append(matches, f)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		si, sj := SpecificityOf(matches[i]), SpecificityOf(matches[j])
+		if si != sj {
+			return si > sj
+		}
+		return matches[i].ID() < matches[j].ID()
+	})
+	return matches
+}
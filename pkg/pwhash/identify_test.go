@@ -0,0 +1,77 @@
+package pwhash_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha1crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha512crypt"
+)
+
+// anyFunction is a Function which parses any encoded hash.
+type anyFunction struct {
+	mariadboldpassword.Function
+	id string
+}
+
+func (f *anyFunction) Parse(encodedHash []byte) ([]byte, []byte, uint,
+	error) {
+	return encodedHash, nil, 0, nil
+}
+
+func (f *anyFunction) ID() string {
+	return f.id
+}
+
+// specificFunction is an anyFunction with a specific encoded form.
+type specificFunction struct {
+	anyFunction
+}
+
+func (*specificFunction) Specificity() pwhash.Specificity {
+	return pwhash.Specific
+}
+
+func TestIdentify(t *testing.T) {
+	functions := map[string]pwhash.Function{
+		mariadboldpassword.ID: &mariadboldpassword.Function{},
+		md5crypt.ID:           &md5crypt.Function{},
+		sha1crypt.ID:          &sha1crypt.Function{},
+		sha256crypt.ID:        &sha256crypt.Function{},
+		sha512crypt.ID:        &sha512crypt.Function{},
+		"zzz":                 &anyFunction{id: "zzz"},
+		"aaa":                 &anyFunction{id: "aaa"},
+		"yyy":                 &specificFunction{anyFunction{id: "yyy"}},
+	}
+	var testCases = map[string]struct {
+		input  string
+		expect []string
+	}{
+		"md5crypt": {
+			input:  `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			expect: []string{"md5crypt", "yyy", "aaa", "zzz"},
+		},
+		"mariaDBOldPassword": {
+			input:  `7196759210defdc0`,
+			expect: []string{"yyy", "aaa", "mariaDBOldPassword", "zzz"},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			// run several times to catch map iteration order dependence
+			for i := 0; i < 10; i++ {
+				var ids []string
+				for _, f := range pwhash.Identify(functions, []byte(tc.input)) {
+					ids = append(ids, f.ID())
+				}
+				if !reflect.DeepEqual(tc.expect, ids) {
+					tt.Fatalf("expected %v, got %v", tc.expect, ids)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Package shacrypt implements the rounds shared by the sha256crypt and
+// sha512crypt hash functions, which differ only in the digest used.
+package shacrypt
+
+import (
+	"context"
+	"fmt"
+	"hash"
+)
+
+const (
+	// cancelInterval is the number of rounds between checks for cancellation.
+	cancelInterval = 1000
+	// patternLen is the number of rounds after which the inputs of the rounds
+	// repeat, since they depend on the round number modulo 2, 3 and 7.
+	patternLen = 42
+)
+
+// Sequence returns b repeated to length n.
+func Sequence(b []byte, n int) []byte {
+	seq := make([]byte, n)
+	for i := 0; i < n; i += len(b) {
+		copy(seq[i:], b)
+	}
+	return seq
+}
+
+// roundPatterns returns the input written to the hash function in each round
+// of the pattern, alongside the intermediate sum. The input depends only on
+// whether the round number is odd, divisible by 3 and divisible by 7, so
+// there are 8 distinct inputs which repeat every patternLen rounds. In odd
+// rounds the input is written before the intermediate sum, and in even rounds
+// after it.
+func roundPatterns(p, s []byte) [patternLen][]byte {
+	var inputs [8][]byte
+	var patterns [patternLen][]byte
+	// the inputs share a buffer large enough for 8 inputs of the maximum
+	// length, so that it is only allocated once
+	buf := make([]byte, 0, 8*(2*len(p)+len(s)))
+	for n := range patterns {
+		odd, div3, div7 := n%2 != 0, n%3 == 0, n%7 == 0
+		i := 0
+		if odd {
+			i |= 1
+		}
+		if div3 {
+			i |= 2
+		}
+		if div7 {
+			i |= 4
+		}
+		if inputs[i] == nil {
+			start := len(buf)
+			// alternate writing P bytes or the intermediate sum
+			if odd {
+				buf = append(buf, p...)
+			}
+			// write S bytes unless divisible by 3
+			if !div3 {
+				buf = append(buf, s...)
+			}
+			// write P bytes unless divisible by 7
+			if !div7 {
+				buf = append(buf, p...)
+			}
+			// alternate writing the intermediate sum or P bytes
+			if !odd {
+				buf = append(buf, p...)
+			}
+			inputs[i] = buf[start:len(buf):len(buf)]
+		}
+		patterns[n] = inputs[i]
+	}
+	return patterns
+}
+
+// Rounds runs the given number of rounds of h, starting from the intermediate
+// sum, with the P bytes p and S bytes s. It returns the final sum, which
+// reuses the storage of sum, or an error wrapping ctx.Err() if ctx is done
+// before the rounds are complete.
+func Rounds(ctx context.Context, h hash.Hash, sum, p, s []byte,
+	cost uint) ([]byte, error) {
+	patterns := roundPatterns(p, s)
+	for n := 0; n < int(cost); n++ {
+		// check for cancellation periodically
+		if n%cancelInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("hash cancelled: %w", err)
+			}
+		}
+		h.Reset()
+		if n%2 != 0 {
+			h.Write(patterns[n%patternLen])
+			h.Write(sum)
+		} else {
+			h.Write(sum)
+			h.Write(patterns[n%patternLen])
+		}
+		sum = h.Sum(sum[:0])
+	}
+	return sum, nil
+}
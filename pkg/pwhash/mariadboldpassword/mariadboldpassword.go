@@ -2,6 +2,7 @@ package mariadboldpassword
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"regexp"
 
@@ -79,6 +80,17 @@ func (*Function) Format(hash, salt []byte, cost uint) string {
 	return string(hash)
 }
 
+// Digest returns the raw digest bytes encoded in the given hash.
+func (*Function) Digest(hash []byte) ([]byte, error) {
+	if !parseRegex.Match(hash) {
+		return nil, fmt.Errorf("couldn't decode %s hash: %w", ID, pwhash.ErrParse)
+	}
+	digest := make([]byte, hex.DecodedLen(len(hash)))
+	// the regex ensures that hash is valid hex
+	hex.Decode(digest, hash) //nolint:errcheck
+	return digest, nil
+}
+
 // ID returns the unique identification string of this hash function.
 func (*Function) ID() string {
 	return ID
@@ -95,3 +107,24 @@ func (*Function) DefaultCost() uint {
 func (*Function) GenerateSalt() ([]byte, error) {
 	return nil, nil
 }
+
+// ValidateSalt returns an error if a salt is given, as the function does not
+// use a salt.
+func (*Function) ValidateSalt(salt []byte) error {
+	if len(salt) > 0 {
+		return fmt.Errorf("%s does not use a salt: %w", ID, pwhash.ErrSaltLen)
+	}
+	return nil
+}
+
+// Verdict returns a weak verdict, since OLD_PASSWORD() is broken.
+func (*Function) Verdict() pwhash.Verdict {
+	return pwhash.Verdict{
+		Weak:   true,
+		Reason: "unsalted, and vulnerable to practical preimage attacks",
+		References: []string{
+			"https://security.stackexchange.com/questions/3133/mysql-old-password-cryptanalysis",
+			"https://nvd.nist.gov/vuln/detail/CVE-2003-1480",
+		},
+	}
+}
@@ -2,50 +2,31 @@ package mariadboldpassword_test
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
+	"github.com/smlx/hashy/pkg/pwhash"
 	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
+	"github.com/smlx/hashy/pkg/pwhash/pwhashtest"
 )
 
-func TestHash(t *testing.T) {
-	var testCases = map[string]struct {
-		input  string
-		expect string
-	}{
-		// test cases taken from
-		// https://github.com/MariaDB/server/blob/10.9/mysql-test/main/func_crypt.result
-		"MariaDB OLD_PASSWORD() test 0": {
-			input:  "idkfa",
-			expect: "5c078dc54ca0fcca",
-		},
-		"MariaDB OLD_PASSWORD() test 1": {
-			input:  "abc",
-			expect: "7cd2b5942be28759",
-		},
-		"MariaDB OLD_PASSWORD() test 2": {
-			input:  "",
-			expect: "",
-		},
-		"MariaDB OLD_PASSWORD() test 3": {
-			input: " i 	 d k f a ",
-			expect: "5c078dc54ca0fcca",
-		},
-		"MariaDB OLD_PASSWORD() test 4": {
-			input:  " i \t d k f a ",
-			expect: "5c078dc54ca0fcca",
-		},
-	}
+func TestVectors(t *testing.T) {
+	pwhashtest.TestVectors(t, &mariadboldpassword.Function{}, "testdata/vectors.jsonl")
+}
+
+func TestFunction(t *testing.T) {
+	pwhashtest.TestFunction(t, &mariadboldpassword.Function{})
+}
+
+func TestHashEmpty(t *testing.T) {
+	// https://github.com/MariaDB/server/blob/10.9/mysql-test/main/func_crypt.result
 	var f mariadboldpassword.Function
-	for name, tc := range testCases {
-		t.Run(name, func(tt *testing.T) {
-			result, err := f.Hash([]byte(tc.input), nil, 0)
-			if err != nil {
-				tt.Fatal(err)
-			}
-			if !bytes.Equal(result, []byte(tc.expect)) {
-				tt.Fatalf("expected %s, got %s", tc.expect, result)
-			}
-		})
+	result, err := f.Hash(nil, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 0 {
+		t.Fatalf("expected empty hash, got %s", result)
 	}
 }
 
@@ -92,3 +73,28 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSalt(t *testing.T) {
+	var testCases = map[string]struct {
+		input  string
+		expect error
+	}{
+		"no salt": {
+			input:  "",
+			expect: nil,
+		},
+		"salt": {
+			input:  "abcd",
+			expect: pwhash.ErrSaltLen,
+		},
+	}
+	var c mariadboldpassword.Function
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			err := c.ValidateSalt([]byte(tc.input))
+			if !errors.Is(err, tc.expect) {
+				tt.Fatalf("expected err %v, got %v", tc.expect, err)
+			}
+		})
+	}
+}
@@ -28,6 +28,11 @@ const (
 var parseRegex = regexp.MustCompile(
 	`^\$1\$(?P<salt>[^$:\n]{1,8})\$(?P<hash>[./0-9A-Za-z]{22})$`)
 
+// permutation is the order in which the bytes of the checksum are encoded.
+var permutation = b64crypt.Permutation{
+	0, 6, 12, 1, 7, 13, 2, 8, 14, 3, 9, 15, 4, 10, 5, 11,
+}
+
 // Function implements the hash.Function interface for the md5 function.
 type Function struct{}
 
@@ -108,18 +113,8 @@ func (*Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
 		// recalculate the checksum
 		sum = md5.Sum(buf.Bytes())
 	}
-	// clear the buffer for the result
-	buf.Reset()
-	// Permute the last checksum again, and encode it in not-quite-base64.
-	// limit is number of bytes in checksum minus bytes encoded after the loop,
-	// divided by three since we write three bytes inside the loop.
-	for i := 0; i < (md5.Size-4)/3; i++ {
-		b64crypt.EncodeBytes(&buf, sum[i], sum[i+6], sum[i+12])
-	}
-	b64crypt.EncodeBytes(&buf, sum[4], sum[10], sum[5])
-	b64crypt.EncodeBytes(&buf, 0, 0, sum[11])
-	// snip the trailing suffix to ignore the final two fully zero twelve bits
-	return buf.Bytes()[:md5.Size*4/3+1], nil
+	// permute the last checksum and encode it in not-quite-base64
+	return permutation.Encode(sum[:]), nil
 }
 
 // Parse the given hash string in its common encoded form.
@@ -143,6 +138,16 @@ func (*Function) Format(hash, salt []byte, cost uint) string {
 	return fmt.Sprintf("%s%s$%s", prefix, salt, hash)
 }
 
+// Digest returns the raw digest bytes encoded in the given hash.
+func (*Function) Digest(hash []byte) ([]byte, error) {
+	digest, err := permutation.Decode(hash)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decode %s hash: %v: %w", ID, err,
+			pwhash.ErrParse)
+	}
+	return digest, nil
+}
+
 // ID returns the unique identification string of this hash function.
 func (*Function) ID() string {
 	return ID
@@ -159,3 +164,35 @@ func (*Function) DefaultCost() uint {
 func (*Function) GenerateSalt() ([]byte, error) {
 	return b64crypt.GenerateSalt(saltMaxLen)
 }
+
+// ValidateSalt checks that the given salt is of valid length and contains only
+// characters which are permitted in the encoded form of this function.
+func (*Function) ValidateSalt(salt []byte) error {
+	if len(salt) < 1 || len(salt) > saltMaxLen {
+		return fmt.Errorf("salt must be 1 to %d bytes: %w", saltMaxLen,
+			pwhash.ErrSaltLen)
+	}
+	if bytes.ContainsAny(salt, "$:\n") {
+		return fmt.Errorf("salt contains '$', ':' or newline: %w",
+			pwhash.ErrSaltCharset)
+	}
+	return nil
+}
+
+// Specificity returns pwhash.Specific, since the encoded form of this function
+// has an identifying prefix.
+func (*Function) Specificity() pwhash.Specificity {
+	return pwhash.Specific
+}
+
+// Verdict returns a weak verdict, since md5crypt is deprecated by its author.
+func (*Function) Verdict() pwhash.Verdict {
+	return pwhash.Verdict{
+		Weak:   true,
+		Reason: "deprecated by its author as too fast to calculate",
+		References: []string{
+			"https://web.archive.org/web/20190324130136/http://phk.freebsd.dk:80/sagas/md5crypt_eol.html",
+			"https://nvd.nist.gov/vuln/detail/CVE-2012-3287",
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package pwhash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// pepperPrefix is the identifier of a peppered encoded hash.
+const pepperPrefix = "$pepper$"
+
+// ErrPepper is returned when a peppered hash cannot be verified because the
+// pepper is not available, or a pepper is invalid.
+var ErrPepper = errors.New("invalid or unknown pepper")
+
+// pepperIDRegex matches a valid pepper ID.
+var pepperIDRegex = regexp.MustCompile(`^[0-9A-Za-z._-]{1,64}$`)
+
+// pepperRegex is used to parse a peppered encoded hash into its component
+// parts.
+var pepperRegex = regexp.MustCompile(
+	`^\$pepper\$(?P<id>[0-9A-Za-z._-]{1,64})\$(?P<hash>.+)$`)
+
+// Pepper is a server-side secret which is combined with a password using
+// HMAC-SHA256 before the password is hashed. A peppered hash has the encoded
+// form:
+//
+//	$pepper$<pepper ID>$<encoded hash>
+//
+// The pepper ID allows peppers to be rotated: hashes generated with an old
+// pepper can still be verified as long as that pepper is available.
+type Pepper struct {
+	// ID identifies the pepper. It may contain up to 64 characters from the
+	// set [0-9A-Za-z._-].
+	ID string
+	// Key is the secret.
+	Key []byte
+}
+
+// validate returns an error wrapping ErrPepper if the pepper is invalid.
+func (p *Pepper) validate() error {
+	if !pepperIDRegex.MatchString(p.ID) {
+		return fmt.Errorf("invalid pepper ID %q: %w", p.ID, ErrPepper)
+	}
+	if len(p.Key) == 0 {
+		return fmt.Errorf("empty pepper key: %w", ErrPepper)
+	}
+	return nil
+}
+
+// Apply returns the HMAC-SHA256 of the password keyed with the pepper. The
+// result is base64 encoded since some functions treat NUL or whitespace bytes
+// in the password specially.
+func (p *Pepper) Apply(password []byte) []byte {
+	mac := hmac.New(sha256.New, p.Key)
+	mac.Write(password)
+	sum := mac.Sum(nil)
+	peppered := make([]byte, base64.StdEncoding.EncodedLen(len(sum)))
+	base64.StdEncoding.Encode(peppered, sum)
+	return peppered
+}
+
+// SplitPepper returns the pepper ID and the inner encoded hash of the given
+// peppered encoded hash. If the hash is not peppered, ok is false.
+func SplitPepper(encodedHash []byte) (id string, inner []byte, ok bool) {
+	matches := pepperRegex.FindSubmatch(encodedHash)
+	if len(matches) < 3 {
+		return "", nil, false
+	}
+	return string(matches[pepperRegex.SubexpIndex("id")]),
+		matches[pepperRegex.SubexpIndex("hash")], true
+}
+
+// stripPepper returns the inner encoded hash if the given encoded hash is
+// peppered, or the encoded hash unchanged otherwise.
+func stripPepper(encodedHash []byte) []byte {
+	if _, inner, ok := SplitPepper(encodedHash); ok {
+		return inner
+	}
+	return encodedHash
+}
+
+// findPepper returns the pepper with the given ID.
+func findPepper(peppers []Pepper, id string) (*Pepper, error) {
+	for i := range peppers {
+		if peppers[i].ID == id {
+			return &peppers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pepper %q not available: %w", id, ErrPepper)
+}
+
+// formatPepper returns the given encoded hash prefixed with the pepper ID.
+func formatPepper(id string, encodedHash string) string {
+	return fmt.Sprintf("%s%s$%s", pepperPrefix, id, encodedHash)
+}
@@ -0,0 +1,55 @@
+package pwhash_test
+
+import (
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+func TestSplitPepper(t *testing.T) {
+	var testCases = map[string]struct {
+		input     string
+		expectID  string
+		expectSub string
+		expectOK  bool
+	}{
+		"peppered": {
+			input:     `$pepper$2023-01$$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			expectID:  "2023-01",
+			expectSub: `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			expectOK:  true,
+		},
+		"not peppered": {
+			input: `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+		},
+		"invalid ID": {
+			input: `$pepper$a b$$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+		},
+		"missing hash": {
+			input: `$pepper$1$`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			id, inner, ok := pwhash.SplitPepper([]byte(tc.input))
+			if ok != tc.expectOK {
+				tt.Fatalf("expected ok %v, got %v", tc.expectOK, ok)
+			}
+			if id != tc.expectID {
+				tt.Fatalf("expected ID %s, got %s", tc.expectID, id)
+			}
+			if string(inner) != tc.expectSub {
+				tt.Fatalf("expected inner hash %s, got %s", tc.expectSub, inner)
+			}
+		})
+	}
+}
+
+func TestPepperApply(t *testing.T) {
+	p := pwhash.Pepper{ID: "1", Key: []byte("key")}
+	// echo -n hashcat | openssl dgst -sha256 -hmac key -binary | base64
+	expect := "7lruMin0A+t2v7y1Ou6G8Ypn+U63o3wYWkpLg3u07Fc="
+	if result := string(p.Apply([]byte("hashcat"))); result != expect {
+		t.Fatalf("expected %s, got %s", expect, result)
+	}
+}
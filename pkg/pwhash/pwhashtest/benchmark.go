@@ -0,0 +1,47 @@
+package pwhashtest
+
+import (
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// benchmarkLongKey is longer than the digest of any of the functions in this
+// module, so that the benchmark covers keys which span more than one digest.
+const benchmarkLongKey = "a very long passphrase of more than one hundred " +
+	"bytes, which exceeds the length of the digest of the hash function"
+
+// benchmarkCostFactor is the multiple of the default cost used to benchmark
+// functions with a variable cost at a high cost.
+const benchmarkCostFactor = 20
+
+// BenchmarkHash benchmarks Hash of f with the given salt, using a short and
+// a long key at the default cost, and a short key at a high cost if f is a
+// pwhash.VariableCost.
+func BenchmarkHash(b *testing.B, f pwhash.Function, salt []byte) {
+	type benchmark struct {
+		name string
+		key  string
+		cost uint
+	}
+	benchmarks := []benchmark{
+		{name: "short key default cost", key: "hashcat", cost: f.DefaultCost()},
+		{name: "long key default cost", key: benchmarkLongKey,
+			cost: f.DefaultCost()},
+	}
+	if _, ok := f.(pwhash.VariableCost); ok {
+		benchmarks = append(benchmarks, benchmark{name: "short key high cost",
+			key: "hashcat", cost: benchmarkCostFactor * f.DefaultCost()})
+	}
+	for _, bm := range benchmarks {
+		bm := bm
+		b.Run(bm.name, func(bb *testing.B) {
+			bb.ReportAllocs()
+			for i := 0; i < bb.N; i++ {
+				if _, err := f.Hash([]byte(bm.key), salt, bm.cost); err != nil {
+					bb.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package pwhashtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// testPassword is the password hashed by TestFunction.
+const testPassword = "hashy test password"
+
+// oversize is the length of the key and salt used to check that Hash rejects
+// oversized input, rather than attempting to hash it. It is larger than the
+// limits of any of the functions in this module.
+const oversize = 1 << 20
+
+// isAny reports whether err wraps any of the given targets.
+func isAny(err error, targets ...error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestFunction checks that f satisfies the contracts of the pwhash.Function
+// interface:
+//
+//   - GenerateSalt returns a salt which is accepted by Hash, and by
+//     ValidateSalt if f is a pwhash.SaltValidator, and which is returned by
+//     Parse of the encoded hash.
+//   - Hash accepts DefaultCost, and is deterministic.
+//   - Format output is accepted by Parse, and pwhash.Verify.
+//   - The hash is accepted by Digest, if f is a pwhash.Digester.
+//   - Errors wrap the pwhash errors: ErrParse from Parse, ErrKeyLen,
+//     ErrSaltLen or ErrSaltCharset for oversized input to Hash, and ErrCost for
+//     costs outside the range of a pwhash.VariableCost.
+//
+// It is intended to be called by the tests of pwhash.Function
+// implementations, including those outside this module.
+func TestFunction(t *testing.T, f pwhash.Function) {
+	t.Helper()
+	if f.ID() == "" {
+		t.Errorf("empty ID")
+	}
+	t.Run("round-trip", func(tt *testing.T) {
+		salt, err := f.GenerateSalt()
+		if err != nil {
+			tt.Fatalf("couldn't generate salt: %v", err)
+		}
+		if err = pwhash.ValidateSalt(f, salt); err != nil {
+			tt.Fatalf("generated salt %q is invalid: %v", salt, err)
+		}
+		cost := f.DefaultCost()
+		hash, err := f.Hash([]byte(testPassword), salt, cost)
+		if err != nil {
+			tt.Fatalf("couldn't hash with generated salt %q and default cost %d: %v",
+				salt, cost, err)
+		}
+		again, err := f.Hash([]byte(testPassword), salt, cost)
+		if err != nil {
+			tt.Fatalf("couldn't hash again: %v", err)
+		}
+		if !bytes.Equal(hash, again) {
+			tt.Fatalf("hash is not deterministic: %s != %s", hash, again)
+		}
+		encodedHash := f.Format(hash, salt, cost)
+		parsedHash, parsedSalt, parsedCost, err := f.Parse([]byte(encodedHash))
+		if err != nil {
+			tt.Fatalf("couldn't parse formatted hash %s: %v", encodedHash, err)
+		}
+		if !bytes.Equal(parsedHash, hash) {
+			tt.Errorf("expected parsed hash %s, got %s", hash, parsedHash)
+		}
+		if !bytes.Equal(parsedSalt, salt) {
+			tt.Errorf("expected parsed salt %q, got %q", salt, parsedSalt)
+		}
+		if parsedCost != cost {
+			tt.Errorf("expected parsed cost %d, got %d", cost, parsedCost)
+		}
+		if d, ok := f.(pwhash.Digester); ok {
+			if _, err = d.Digest(hash); err != nil {
+				tt.Errorf("couldn't get digest of hash %s: %v", hash, err)
+			}
+		}
+		match, err := pwhash.Verify(context.Background(), f, []byte(encodedHash),
+			[]byte(testPassword), nil)
+		if err != nil {
+			tt.Fatalf("couldn't verify formatted hash %s: %v", encodedHash, err)
+		}
+		if !match {
+			tt.Errorf("password doesn't match formatted hash %s", encodedHash)
+		}
+	})
+	t.Run("parse errors", func(tt *testing.T) {
+		for _, input := range []string{"", "$", "not a password hash", "\x00\xff"} {
+			_, _, _, err := f.Parse([]byte(input))
+			if !errors.Is(err, pwhash.ErrParse) {
+				tt.Errorf("expected err %v parsing %q, got %v", pwhash.ErrParse, input,
+					err)
+			}
+		}
+	})
+	t.Run("hash errors", func(tt *testing.T) {
+		salt, err := f.GenerateSalt()
+		if err != nil {
+			tt.Fatalf("couldn't generate salt: %v", err)
+		}
+		cost := f.DefaultCost()
+		// oversized input may be accepted, but must otherwise be rejected with
+		// the appropriate error
+		key := bytes.Repeat([]byte("k"), oversize)
+		if _, err = f.Hash(key, salt, cost); err != nil &&
+			!errors.Is(err, pwhash.ErrKeyLen) {
+			tt.Errorf("expected err %v for oversized key, got %v", pwhash.ErrKeyLen,
+				err)
+		}
+		bigSalt := bytes.Repeat([]byte("s"), oversize)
+		if err = pwhash.ValidateSalt(f, bigSalt); err != nil &&
+			!isAny(err, pwhash.ErrSaltLen, pwhash.ErrSaltCharset) {
+			tt.Errorf("expected err %v or %v validating oversized salt, got %v",
+				pwhash.ErrSaltLen, pwhash.ErrSaltCharset, err)
+		}
+		if _, err = f.Hash([]byte(testPassword), bigSalt, cost); err != nil &&
+			!isAny(err, pwhash.ErrSaltLen, pwhash.ErrSaltCharset) {
+			tt.Errorf("expected err %v or %v for oversized salt, got %v",
+				pwhash.ErrSaltLen, pwhash.ErrSaltCharset, err)
+		}
+		vc, ok := f.(pwhash.VariableCost)
+		if !ok {
+			return
+		}
+		minCost, maxCost := vc.CostRange()
+		if minCost > maxCost || cost < minCost || cost > maxCost {
+			tt.Errorf("default cost %d outside cost range %d-%d", cost, minCost,
+				maxCost)
+		}
+		if minCost > 0 {
+			if _, err = f.Hash([]byte(testPassword), salt, minCost-1); !errors.Is(err,
+				pwhash.ErrCost) {
+				tt.Errorf("expected err %v for cost %d, got %v", pwhash.ErrCost,
+					minCost-1, err)
+			}
+		}
+		if maxCost < math.MaxUint {
+			if _, err = f.Hash([]byte(testPassword), salt, maxCost+1); !errors.Is(err,
+				pwhash.ErrCost) {
+				tt.Errorf("expected err %v for cost %d, got %v", pwhash.ErrCost,
+					maxCost+1, err)
+			}
+		}
+	})
+}
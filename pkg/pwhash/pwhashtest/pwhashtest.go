@@ -0,0 +1,179 @@
+// Package pwhashtest implements utilities for testing pwhash.Function
+// implementations.
+//
+// Test vectors are stored in JSON lines files, conventionally in the testdata
+// directory of the package under test. Each line is a JSON object with the
+// fields of Vector, for example:
+//
+//	{"function":"md5crypt","password":"foo","salt":"V0I8Ox6J","cost":0,"hash":"$1$V0I8Ox6J$I5JKgWHoC9o7ugE.JLcar/"}
+//
+// Passwords which are not valid UTF-8 are given as hex in the password_hex
+// field instead.
+package pwhashtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+// Vector is a test vector for a pwhash.Function.
+type Vector struct {
+	// Function is the ID of the function.
+	Function string `json:"function"`
+	// Password is the password which is hashed.
+	Password string `json:"password,omitempty"`
+	// PasswordHex is the hex-encoded password which is hashed. It is used
+	// instead of Password if it is not empty.
+	PasswordHex string `json:"password_hex,omitempty"`
+	// Salt is the salt passed to the function.
+	Salt string `json:"salt"`
+	// Cost is the cost passed to the function.
+	Cost uint `json:"cost"`
+	// Hash is the expected hash in encoded form.
+	Hash string `json:"hash"`
+	// Comment optionally describes the source of the vector.
+	Comment string `json:"comment,omitempty"`
+
+	// name identifies the vector in test output.
+	name string
+}
+
+// Name returns the file and line of the vector, if it was read by
+// ReadVectors.
+func (v *Vector) Name() string {
+	return v.name
+}
+
+// password returns the decoded password of the vector.
+func (v *Vector) password() ([]byte, error) {
+	if v.PasswordHex != "" {
+		return hex.DecodeString(v.PasswordHex)
+	}
+	return []byte(v.Password), nil
+}
+
+// ReadVectors reads the test vectors in the given JSON lines file. Empty lines
+// are ignored.
+func ReadVectors(name string) ([]Vector, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var vectors []Vector
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var v Vector
+		dec := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, line, err)
+		}
+		if _, err = v.password(); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid password_hex: %v", name, line,
+				err)
+		}
+		v.name = fmt.Sprintf("%s:%d", name, line)
+		vectors = append(vectors, v)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %v", name, err)
+	}
+	return vectors, nil
+}
+
+// TestVectors reads the test vectors in the given JSON lines file and checks f
+// against each vector with CheckVector. Vectors for other functions are
+// ignored, but it is an error if the file contains no vectors for f.
+func TestVectors(t *testing.T, f pwhash.Function, name string) {
+	t.Helper()
+	vectors, err := ReadVectors(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	for _, v := range vectors {
+		if v.Function != f.ID() {
+			continue
+		}
+		n++
+		CheckVector(t, f, v)
+	}
+	if n == 0 {
+		t.Fatalf("no %s vectors in %s", f.ID(), name)
+	}
+}
+
+// CheckVector checks that:
+//
+//   - Hash of the password, salt and cost of the vector, formatted by Format,
+//     is the expected encoded hash, or the form produced by Format of the
+//     parsed expected encoded hash. The latter permits vectors from tools
+//     which omit default parameters, such as the rounds of sha256crypt.
+//   - Parse of the expected encoded hash returns the hash, salt and cost.
+//   - pwhash.Verify of the password against the expected encoded hash
+//     succeeds, and fails for a different password.
+func CheckVector(t *testing.T, f pwhash.Function, v Vector) {
+	t.Helper()
+	password, err := v.password()
+	if err != nil {
+		t.Errorf("%s: invalid password_hex: %v", v.name, err)
+		return
+	}
+	hash, err := f.Hash(password, []byte(v.Salt), v.Cost)
+	if err != nil {
+		t.Errorf("%s: couldn't hash: %v", v.name, err)
+		return
+	}
+	parsedHash, salt, cost, err := f.Parse([]byte(v.Hash))
+	if err != nil {
+		t.Errorf("%s: couldn't parse: %v", v.name, err)
+		return
+	}
+	if encodedHash := f.Format(hash, []byte(v.Salt), v.Cost); encodedHash !=
+		v.Hash && encodedHash != f.Format(parsedHash, salt, cost) {
+		t.Errorf("%s: expected encoded hash %s, got %s", v.name, v.Hash,
+			encodedHash)
+		return
+	}
+	if !bytes.Equal(parsedHash, hash) {
+		t.Errorf("%s: expected parsed hash %s, got %s", v.name, hash, parsedHash)
+	}
+	if string(salt) != v.Salt {
+		t.Errorf("%s: expected parsed salt %s, got %s", v.name, v.Salt, salt)
+	}
+	if cost != v.Cost {
+		t.Errorf("%s: expected parsed cost %d, got %d", v.name, v.Cost, cost)
+	}
+	// permit the cost of the vector, which may exceed the default limit
+	opts := &pwhash.VerifyOptions{MaxCost: map[string]uint{f.ID(): v.Cost}}
+	for _, tc := range []struct {
+		password []byte
+		expect   bool
+	}{
+		{password: password, expect: true},
+		{password: append([]byte("not"), password...), expect: false},
+	} {
+		match, err := pwhash.Verify(context.Background(), f, []byte(v.Hash),
+			tc.password, opts)
+		if err != nil {
+			t.Errorf("%s: couldn't verify: %v", v.name, err)
+			continue
+		}
+		if match != tc.expect {
+			t.Errorf("%s: expected match %v, got %v", v.name, tc.expect, match)
+		}
+	}
+}
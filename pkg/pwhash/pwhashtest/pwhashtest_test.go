@@ -0,0 +1,60 @@
+package pwhashtest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash/pwhashtest"
+)
+
+func TestReadVectors(t *testing.T) {
+	var testCases = map[string]struct {
+		input     string
+		expectLen int
+		expectErr bool
+	}{
+		"valid": {
+			input: `{"function":"md5crypt","password":"foo","salt":"V0I8Ox6J","cost":0,"hash":"$1$V0I8Ox6J$I5JKgWHoC9o7ugE.JLcar/"}` +
+				"\n\n" +
+				`{"function":"md5crypt","password_hex":"666f6f","salt":"V0I8Ox6J","cost":0,"hash":"$1$V0I8Ox6J$I5JKgWHoC9o7ugE.JLcar/"}`,
+			expectLen: 2,
+		},
+		"unknown field": {
+			input:     `{"function":"md5crypt","rounds":1}`,
+			expectErr: true,
+		},
+		"invalid hex": {
+			input:     `{"function":"md5crypt","password_hex":"xyz"}`,
+			expectErr: true,
+		},
+		"invalid json": {
+			input:     `{"function":`,
+			expectErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			file := filepath.Join(tt.TempDir(), "vectors.jsonl")
+			if err := os.WriteFile(file, []byte(tc.input), 0600); err != nil {
+				tt.Fatal(err)
+			}
+			vectors, err := pwhashtest.ReadVectors(file)
+			if tc.expectErr {
+				if err == nil {
+					tt.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if len(vectors) != tc.expectLen {
+				tt.Fatalf("expected %d vectors, got %d", tc.expectLen, len(vectors))
+			}
+			if expect := file + ":3"; vectors[1].Name() != expect {
+				tt.Fatalf("expected name %s, got %s", expect, vectors[1].Name())
+			}
+		})
+	}
+}
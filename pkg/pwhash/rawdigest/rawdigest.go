@@ -0,0 +1,284 @@
+// Package rawdigest implements a family of pwhash.Functions which calculate
+// a single unsalted-style digest of the password concatenated with a salt, as
+// used by many homegrown applications. For example sha256(s.p) is the SHA256
+// digest of the salt followed by the password.
+//
+// These functions are not suitable for storing passwords, since they are
+// very fast to calculate. They are implemented so that existing hashes can be
+// identified and checked.
+//
+// The encoded form of a hash is the hex or base64 digest followed by a colon
+// and the salt, as used by hashcat:
+//
+//	<digest>:<salt>
+package rawdigest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"regexp"
+
+	"github.com/smlx/hashy/pkg/b64crypt"
+	"github.com/smlx/hashy/pkg/pwhash"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// saltMaxLen is an arbitrary limit on the salt length
+	saltMaxLen = 256
+	// saltGenLen is the length of generated salts
+	saltGenLen = 16
+	// keyMaxLen sets an arbitrary 32K limit to avoid DoS
+	keyMaxLen = 1 << 15
+)
+
+// Algorithm is a digest algorithm.
+type Algorithm struct {
+	name string
+	new  func() hash.Hash
+}
+
+// Algorithms supported by this package.
+var (
+	MD5      = Algorithm{name: "md5", new: md5.New}
+	SHA1     = Algorithm{name: "sha1", new: sha1.New}
+	SHA224   = Algorithm{name: "sha224", new: sha256.New224}
+	SHA256   = Algorithm{name: "sha256", new: sha256.New}
+	SHA384   = Algorithm{name: "sha384", new: sha512.New384}
+	SHA512   = Algorithm{name: "sha512", new: sha512.New}
+	SHA3_224 = Algorithm{name: "sha3-224", new: sha3.New224}
+	SHA3_256 = Algorithm{name: "sha3-256", new: sha3.New256}
+	SHA3_384 = Algorithm{name: "sha3-384", new: sha3.New384}
+	SHA3_512 = Algorithm{name: "sha3-512", new: sha3.New512}
+)
+
+// Algorithms returns the algorithms supported by this package.
+func Algorithms() []Algorithm {
+	return []Algorithm{MD5, SHA1, SHA224, SHA256, SHA384, SHA512, SHA3_224,
+		SHA3_256, SHA3_384, SHA3_512}
+}
+
+// Name returns the name of the algorithm as used in function IDs, such as
+// sha256 or sha3-256.
+func (a Algorithm) Name() string {
+	return a.name
+}
+
+// New returns a new hash.Hash calculating the digest.
+func (a Algorithm) New() hash.Hash {
+	return a.new()
+}
+
+// SaltPosition is the position of the salt relative to the password.
+type SaltPosition int
+
+// Salt positions.
+const (
+	// Prefix places the salt before the password: s.p
+	Prefix SaltPosition = iota
+	// Suffix places the salt after the password: p.s
+	Suffix
+	// Both places the salt before and after the password: s.p.s
+	Both
+)
+
+// String returns the concatenation of salt and password in the notation used
+// by the function ID.
+func (p SaltPosition) String() string {
+	switch p {
+	case Prefix:
+		return "s.p"
+	case Suffix:
+		return "p.s"
+	case Both:
+		return "s.p.s"
+	default:
+		return "unknown"
+	}
+}
+
+// Encoding is the encoding of the digest.
+type Encoding int
+
+// Encodings.
+const (
+	// Hex encodes the digest in lower case hexadecimal.
+	Hex Encoding = iota
+	// Base64 encodes the digest in padded RFC4648 base64.
+	Base64
+)
+
+// Function implements the pwhash.Function interface for a digest algorithm,
+// salt position and encoding.
+type Function struct {
+	alg        Algorithm
+	pos        SaltPosition
+	enc        Encoding
+	parseRegex *regexp.Regexp
+}
+
+// New returns a Function which calculates the digest of the password and
+// salt concatenated as given by pos, encoded with enc.
+func New(alg Algorithm, pos SaltPosition, enc Encoding) *Function {
+	size := alg.new().Size()
+	var hashRegex string
+	switch enc {
+	case Hex:
+		hashRegex = fmt.Sprintf(`[0-9a-fA-F]{%d}`, hex.EncodedLen(size))
+	case Base64:
+		hashRegex = fmt.Sprintf(`[0-9A-Za-z+/=]{%d}`,
+			base64.StdEncoding.EncodedLen(size))
+	}
+	return &Function{
+		alg: alg,
+		pos: pos,
+		enc: enc,
+		parseRegex: regexp.MustCompile(fmt.Sprintf(
+			`^(?P<hash>%s):(?P<salt>[^\n]{1,%d})$`, hashRegex, saltMaxLen)),
+	}
+}
+
+// Functions returns a Function for each combination of the algorithms, salt
+// positions and encodings supported by this package.
+func Functions() []*Function {
+	var functions []*Function
+	for _, alg := range Algorithms() {
+		for _, pos := range []SaltPosition{Prefix, Suffix, Both} {
+			for _, enc := range []Encoding{Hex, Base64} {
+				functions = append(functions, New(alg, pos, enc))
+			}
+		}
+	}
+	return functions
+}
+
+// Hash returns the encoded digest of the given key and salt. The cost
+// argument is ignored, since these functions have no such parameter.
+func (f *Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
+	// perform some safety checks
+	if len(key) > keyMaxLen {
+		return nil, fmt.Errorf("key longer than %d bytes: %w", keyMaxLen,
+			pwhash.ErrKeyLen)
+	}
+	if err := f.ValidateSalt(salt); err != nil {
+		return nil, err
+	}
+	h := f.alg.new()
+	// h.Write never returns an error
+	switch f.pos {
+	case Prefix:
+		h.Write(salt)
+		h.Write(key)
+	case Suffix:
+		h.Write(key)
+		h.Write(salt)
+	case Both:
+		h.Write(salt)
+		h.Write(key)
+		h.Write(salt)
+	}
+	sum := h.Sum(nil)
+	var encoded []byte
+	switch f.enc {
+	case Hex:
+		encoded = make([]byte, hex.EncodedLen(len(sum)))
+		hex.Encode(encoded, sum)
+	case Base64:
+		encoded = make([]byte, base64.StdEncoding.EncodedLen(len(sum)))
+		base64.StdEncoding.Encode(encoded, sum)
+	}
+	return encoded, nil
+}
+
+// Parse the given hash string in its common encoded form. Hex digests are
+// converted to lower case.
+func (f *Function) Parse(encodedHash []byte) ([]byte, []byte, uint, error) {
+	matches := f.parseRegex.FindSubmatch(encodedHash)
+	if len(matches) < 3 {
+		return nil, nil, 0, fmt.Errorf("couldn't parse %s format: %w", f.ID(),
+			pwhash.ErrParse)
+	}
+	hash := matches[f.parseRegex.SubexpIndex("hash")]
+	if f.enc == Hex {
+		hash = bytes.ToLower(hash)
+	}
+	if _, err := f.Digest(hash); err != nil {
+		return nil, nil, 0, err
+	}
+	return hash, matches[f.parseRegex.SubexpIndex("salt")], 0, nil
+}
+
+// Format the given parameters into the common "password hash" form. The
+// cost parameter is not used by these functions.
+func (*Function) Format(hash, salt []byte, cost uint) string {
+	return fmt.Sprintf("%s:%s", hash, salt)
+}
+
+// Digest returns the raw digest bytes encoded in the given hash.
+func (f *Function) Digest(hash []byte) ([]byte, error) {
+	var digest []byte
+	var err error
+	switch f.enc {
+	case Hex:
+		digest, err = hex.DecodeString(string(hash))
+	case Base64:
+		digest, err = base64.StdEncoding.Strict().DecodeString(string(hash))
+	}
+	if err != nil || len(digest) != f.alg.new().Size() {
+		return nil, fmt.Errorf("couldn't decode %s hash: %w", f.ID(),
+			pwhash.ErrParse)
+	}
+	return digest, nil
+}
+
+// ID returns the unique identification string of this hash function, such as
+// sha256(s.p) or base64(sha256(s.p)).
+func (f *Function) ID() string {
+	id := fmt.Sprintf("%s(%s)", f.alg.name, f.pos)
+	if f.enc == Base64 {
+		return fmt.Sprintf("base64(%s)", id)
+	}
+	return id
+}
+
+// DefaultCost returns zero, since these functions have no cost parameter.
+func (*Function) DefaultCost() uint {
+	return 0
+}
+
+// GenerateSalt returns a cryptographically secure salt value.
+func (*Function) GenerateSalt() ([]byte, error) {
+	return b64crypt.GenerateSalt(saltGenLen)
+}
+
+// ValidateSalt checks that the given salt is of valid length and contains no
+// newline, which can't be represented in the encoded form.
+func (*Function) ValidateSalt(salt []byte) error {
+	if len(salt) < 1 || len(salt) > saltMaxLen {
+		return fmt.Errorf("salt must be 1 to %d bytes: %w", saltMaxLen,
+			pwhash.ErrSaltLen)
+	}
+	if bytes.ContainsRune(salt, '\n') {
+		return fmt.Errorf("salt contains newline: %w", pwhash.ErrSaltCharset)
+	}
+	return nil
+}
+
+// Verdict returns a weak verdict, since a single digest is too fast to
+// calculate.
+func (*Function) Verdict() pwhash.Verdict {
+	return pwhash.Verdict{
+		Weak:   true,
+		Reason: "a single fast digest, which is unsuitable for storing passwords",
+		References: []string{
+			"https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#password-hashing-algorithms",
+		},
+	}
+}
@@ -0,0 +1,123 @@
+package rawdigest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/pwhashtest"
+	"github.com/smlx/hashy/pkg/pwhash/rawdigest"
+)
+
+func TestVectors(t *testing.T) {
+	for _, f := range rawdigest.Functions() {
+		t.Run(f.ID(), func(tt *testing.T) {
+			pwhashtest.TestVectors(tt, f, "testdata/vectors.jsonl")
+		})
+	}
+}
+
+func TestFunction(t *testing.T) {
+	for _, f := range rawdigest.Functions() {
+		t.Run(f.ID(), func(tt *testing.T) {
+			pwhashtest.TestFunction(tt, f)
+		})
+	}
+}
+
+type parseOutput struct {
+	hash []byte
+	salt []byte
+	err  error
+}
+
+func TestParse(t *testing.T) {
+	var testCases = map[string]struct {
+		function *rawdigest.Function
+		input    string
+		expect   parseOutput
+	}{
+		"upper case hex": {
+			function: rawdigest.New(rawdigest.MD5, rawdigest.Prefix, rawdigest.Hex),
+			input:    "E0DC808D4120602679E730D130C0126E:28772684",
+			expect: parseOutput{
+				hash: []byte("e0dc808d4120602679e730d130c0126e"),
+				salt: []byte("28772684"),
+			},
+		},
+		"salt with colons": {
+			function: rawdigest.New(rawdigest.MD5, rawdigest.Prefix, rawdigest.Hex),
+			input:    "0df749ecdcddf960889d9fecf74c181f:salt:with:colons",
+			expect: parseOutput{
+				hash: []byte("0df749ecdcddf960889d9fecf74c181f"),
+				salt: []byte("salt:with:colons"),
+			},
+		},
+		"no salt": {
+			function: rawdigest.New(rawdigest.MD5, rawdigest.Prefix, rawdigest.Hex),
+			input:    "0df749ecdcddf960889d9fecf74c181f",
+			expect:   parseOutput{err: pwhash.ErrParse},
+		},
+		"empty salt": {
+			function: rawdigest.New(rawdigest.MD5, rawdigest.Prefix, rawdigest.Hex),
+			input:    "0df749ecdcddf960889d9fecf74c181f:",
+			expect:   parseOutput{err: pwhash.ErrParse},
+		},
+		"wrong digest length": {
+			function: rawdigest.New(rawdigest.SHA1, rawdigest.Prefix, rawdigest.Hex),
+			input:    "0df749ecdcddf960889d9fecf74c181f:salt",
+			expect:   parseOutput{err: pwhash.ErrParse},
+		},
+		"misplaced base64 padding": {
+			function: rawdigest.New(rawdigest.MD5, rawdigest.Prefix, rawdigest.Base64),
+			input:    "=N9JntzdA+YiJ2f7PdMGB8=:salt",
+			expect:   parseOutput{err: pwhash.ErrParse},
+		},
+		"non-canonical base64": {
+			function: rawdigest.New(rawdigest.MD5, rawdigest.Prefix, rawdigest.Base64),
+			input:    "DfdJ7NzfYIiJ2f7PdMGB9==:salt",
+			expect:   parseOutput{err: pwhash.ErrParse},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			hash, salt, _, err := tc.function.Parse([]byte(tc.input))
+			if !errors.Is(err, tc.expect.err) {
+				tt.Fatalf("expected err %v, got %v", tc.expect.err, err)
+			}
+			if string(hash) != string(tc.expect.hash) {
+				tt.Fatalf("expected hash %s, got %s", tc.expect.hash, hash)
+			}
+			if string(salt) != string(tc.expect.salt) {
+				tt.Fatalf("expected salt %s, got %s", tc.expect.salt, salt)
+			}
+		})
+	}
+}
+
+func TestID(t *testing.T) {
+	var testCases = map[string]struct {
+		function *rawdigest.Function
+		expect   string
+	}{
+		"hex prefix": {
+			function: rawdigest.New(rawdigest.SHA256, rawdigest.Prefix, rawdigest.Hex),
+			expect:   "sha256(s.p)",
+		},
+		"hex both": {
+			function: rawdigest.New(rawdigest.SHA3_512, rawdigest.Both, rawdigest.Hex),
+			expect:   "sha3-512(s.p.s)",
+		},
+		"base64 suffix": {
+			function: rawdigest.New(rawdigest.MD5, rawdigest.Suffix, rawdigest.Base64),
+			expect:   "base64(md5(p.s))",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			if id := tc.function.ID(); id != tc.expect {
+				tt.Fatalf("expected %s, got %s", tc.expect, id)
+			}
+		})
+	}
+}
@@ -1,7 +1,7 @@
 package sha1crypt
 
 import (
-	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha1"
 	"fmt"
@@ -30,15 +30,27 @@ const (
 	costDefault = 1 << 18
 	// costMax is the minimum number of iterations used by this hash function.
 	costMin = 1
+	// cancelInterval is the number of rounds between checks for cancellation.
+	cancelInterval = 1000
 )
 
 // parseRegex is used to parse the formatted hash into its component parts.
 // This regex is taken from the libxcrypt manpage, fixed (the manpage regex is
 // wrong), and extended with capture groups.
 var parseRegex = regexp.MustCompile(
-	`^\$sha1\$(?P<cost>[1-9][0-9]+)\$(?P<salt>[./0-9A-Za-z]{1,64})\$` +
+	`^\$sha1\$(?P<cost>[1-9][0-9]*)\$(?P<salt>[./0-9A-Za-z]{1,64})\$` +
 		`(?P<hash>[./0-9A-Za-z]{28})$`)
 
+// saltRegex matches the characters permitted in a salt.
+var saltRegex = regexp.MustCompile(`^[./0-9A-Za-z]*$`)
+
+// permutation is the order in which the bytes of the checksum are encoded.
+// The first byte is encoded twice to produce an integer number of encoded
+// groups i.e. (20+1)*4/3 = 28.0
+var permutation = b64crypt.Permutation{
+	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 0,
+}
+
 // Function implements the hash.Function interface for the md5 function.
 type Function struct{}
 
@@ -47,7 +59,14 @@ type Function struct{}
 //
 // Warning: The permutation logic in this function reflects the cryptographic
 // era in which it was written.
-func (*Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
+func (f *Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
+	return f.HashContext(context.Background(), key, salt, cost)
+}
+
+// HashContext is like Hash, but returns an error wrapping ctx.Err() if ctx is
+// done before the hash is calculated.
+func (*Function) HashContext(ctx context.Context, key, salt []byte,
+	cost uint) ([]byte, error) {
 	// perform some safety checks
 	if len(key) > keyMaxLen {
 		return nil, fmt.Errorf("key longer than %d bytes: %w", keyMaxLen,
@@ -75,19 +94,17 @@ func (*Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
 	// run cost number of rounds
 	sum := h.Sum(nil)
 	for i := uint(1); i < cost; i++ {
+		// check for cancellation periodically
+		if i%cancelInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("hash cancelled: %w", err)
+			}
+		}
 		h = hmac.New(sha1.New, key)
 		h.Write(sum)
 		sum = h.Sum(nil)
 	}
-	var buf bytes.Buffer
-	for i := 0; i < sha1.Size-3; i += 3 {
-		b64crypt.EncodeBytes(&buf, sum[i], sum[i+1], sum[i+2])
-	}
-	b64crypt.EncodeBytes(&buf, sum[sha1.Size-2], sum[sha1.Size-1], sum[0])
-	// no need to snip the trailing suffix here since the sum[0] byte is encoded
-	// twice to produce an integer number of bytes in the encoded checksum
-	// i.e. (20+1)*4/3 = 28.0
-	return buf.Bytes(), nil
+	return permutation.Encode(sum), nil
 }
 
 // Parse the given hash string in its common encoded form.
@@ -117,6 +134,16 @@ func (*Function) Format(hash, salt []byte, cost uint) string {
 	return fmt.Sprintf("%s%d$%s$%s", prefix, cost, salt, hash)
 }
 
+// Digest returns the raw digest bytes encoded in the given hash.
+func (*Function) Digest(hash []byte) ([]byte, error) {
+	digest, err := permutation.Decode(hash)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decode %s hash: %v: %w", ID, err,
+			pwhash.ErrParse)
+	}
+	return digest, nil
+}
+
 // ID returns the unique identification string of this hash function.
 func (*Function) ID() string {
 	return ID
@@ -132,3 +159,40 @@ func (*Function) DefaultCost() uint {
 func (*Function) GenerateSalt() ([]byte, error) {
 	return b64crypt.GenerateSalt(saltMaxLen)
 }
+
+// ValidateSalt checks that the given salt is of valid length and contains only
+// characters which are permitted in the encoded form of this function.
+func (*Function) ValidateSalt(salt []byte) error {
+	if len(salt) < 1 || len(salt) > saltMaxLen {
+		return fmt.Errorf("salt must be 1 to %d bytes: %w", saltMaxLen,
+			pwhash.ErrSaltLen)
+	}
+	if !saltRegex.Match(salt) {
+		return fmt.Errorf("salt contains characters other than [./0-9A-Za-z]: %w",
+			pwhash.ErrSaltCharset)
+	}
+	return nil
+}
+
+// Specificity returns pwhash.Specific, since the encoded form of this function
+// has an identifying prefix.
+func (*Function) Specificity() pwhash.Specificity {
+	return pwhash.Specific
+}
+
+// CostRange returns the minimum and maximum cost values accepted by Hash.
+func (*Function) CostRange() (uint, uint) {
+	return costMin, costMax
+}
+
+// Verdict returns a weak verdict, since sha1crypt is not recommended for new
+// hashes.
+func (*Function) Verdict() pwhash.Verdict {
+	return pwhash.Verdict{
+		Weak:   true,
+		Reason: "not recommended for new hashes",
+		References: []string{
+			"https://manpages.debian.org/testing/libcrypt-dev/crypt.5.en.html#sha1crypt",
+		},
+	}
+}
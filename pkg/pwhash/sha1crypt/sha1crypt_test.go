@@ -2,73 +2,21 @@ package sha1crypt_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/pwhashtest"
 	"github.com/smlx/hashy/pkg/pwhash/sha1crypt"
 )
 
-type hashTestInput struct {
-	password string
-	salt     string
-	cost     uint
+func TestVectors(t *testing.T) {
+	pwhashtest.TestVectors(t, &sha1crypt.Function{}, "testdata/vectors.jsonl")
 }
 
-func TestHash(t *testing.T) {
-	var testCases = map[string]struct {
-		input  hashTestInput
-		expect string
-	}{
-		// https://github.com/openwall/john/blob/bleeding-jumbo/src/sha1crypt_common_plug.c
-		"john 2": {
-			input:  hashTestInput{"password", "wnUR8T1U", 64000},
-			expect: "vt1TFQ50tBMFgkflAFAOer2CwdYZ",
-		},
-		"john 3": {
-			input:  hashTestInput{"password", "jtNX3nZ2", 40000},
-			expect: "hBNaIXkt4wBI2o5rsi8KejSjNqIq",
-		},
-		"john 4": {
-			input:  hashTestInput{"123456", "wnUR8T1U", 64000},
-			expect: "wmwnhQ4lpo/5isi5iewkrHN7DjrT",
-		},
-		"john 5": {
-			input:  hashTestInput{"complexlongpassword@123456", "wnUR8T1U", 64000},
-			expect: "azjCegpOIk0FjE61qzGWhdkpuMRL",
-		},
-		"juniper 1": {
-			input:  hashTestInput{"Hashcat1234!", "i3Znp47D", 24659},
-			expect: "r7VOjnryOmiGNWRpna0Lk3ooe/jX",
-		},
-		"juniper 2": {
-			input:  hashTestInput{"Hashcat1234!", "SeTzdv2R", 19205},
-			expect: "8ZcgMk0PiGRrQdz5xGMncAfymq1C",
-		},
-		"passlib 1": {
-			input:  hashTestInput{"foo", "NSb4QDqW", 2},
-			expect: "HBpkSg32map7FLee9lVOGRmy1b.T",
-		},
-		"juniper 3": {
-			input:  hashTestInput{"flipfl0p!", "mROzSQ4a", 19295},
-			expect: "SFnJ1fAbP4cHqw/16.xDV4s1LpMA",
-		},
-		"juniper 4": {
-			input:  hashTestInput{"stuff", "/WgTkHoe", 23933},
-			expect: "25rdwdZ95cfgY/Tl6li2/LRIbuVT",
-		},
-	}
-	var c sha1crypt.Function
-	for name, tc := range testCases {
-		t.Run(name, func(tt *testing.T) {
-			result, err := c.Hash([]byte(tc.input.password), []byte(tc.input.salt),
-				tc.input.cost)
-			if err != nil {
-				tt.Fatal(err)
-			}
-			if !bytes.Equal(result, []byte(tc.expect)) {
-				tt.Fatalf("expected %s, got %s", tc.expect, string(result))
-			}
-		})
-	}
+func TestFunction(t *testing.T) {
+	pwhashtest.TestFunction(t, &sha1crypt.Function{})
 }
 
 type parseOutput struct {
@@ -154,3 +102,47 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSalt(t *testing.T) {
+	var testCases = map[string]struct {
+		input  string
+		expect error
+	}{
+		"valid": {
+			input:  "jtNX3nZ2",
+			expect: nil,
+		},
+		"empty": {
+			input:  "",
+			expect: pwhash.ErrSaltLen,
+		},
+		"too long": {
+			input:  "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			expect: pwhash.ErrSaltLen,
+		},
+		"invalid character": {
+			input:  "jtNX3n-2",
+			expect: pwhash.ErrSaltCharset,
+		},
+	}
+	var c sha1crypt.Function
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			err := c.ValidateSalt([]byte(tc.input))
+			if !errors.Is(err, tc.expect) {
+				tt.Fatalf("expected err %v, got %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func TestHashContext(t *testing.T) {
+	var c sha1crypt.Function
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.HashContext(ctx, []byte("foo"), []byte("jtNX3nZ2"),
+		c.DefaultCost())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected err %v, got %v", context.Canceled, err)
+	}
+}
@@ -2,14 +2,15 @@ package sha256crypt
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"fmt"
-	"hash"
 	"regexp"
 	"strconv"
 
 	"github.com/smlx/hashy/pkg/b64crypt"
 	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/internal/shacrypt"
 )
 
 const (
@@ -38,24 +39,27 @@ var parseRegex = regexp.MustCompile(
 	`^\$5\$(?:rounds=(?P<cost>[1-9][0-9]+)\$)?(?P<salt>[^$:\n]{1,16})\$` +
 		`(?P<hash>[./0-9A-Za-z]{43})$`)
 
+// permutation is the order in which the bytes of the checksum are encoded.
+var permutation = b64crypt.Permutation{
+	0, 10, 20, 21, 1, 11, 12, 22, 2, 3, 13, 23, 24, 4, 14, 15, 25, 5, 6, 16, 26,
+	27, 7, 17, 18, 28, 8, 9, 19, 29, 31, 30,
+}
+
 // Function implements the hash.Function interface for the md5 function.
 type Function struct{}
 
-// recycle repeatedly writes b to h.
-func recycle(h hash.Hash, b []byte, keylen int) {
-	var n int
-	for n = keylen; n >= 32; n -= 32 {
-		h.Write(b)
-	}
-	h.Write(b[:n])
-}
-
-// Hash returns the hash of the given key. The cost argument is ignored, since
-// md5crypt has no such parameter.
+// Hash returns the hash of the given key using cost rounds.
 //
 // Warning: The permutation logic in this function reflects the cryptographic
 // era in which it was written.
-func (*Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
+func (f *Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
+	return f.HashContext(context.Background(), key, salt, cost)
+}
+
+// HashContext is like Hash, but returns an error wrapping ctx.Err() if ctx is
+// done before the hash is calculated.
+func (*Function) HashContext(ctx context.Context, key, salt []byte,
+	cost uint) ([]byte, error) {
 	// perform some safety checks
 	if len(key) > keyMaxLen {
 		return nil, fmt.Errorf("key longer than %d bytes: %w", keyMaxLen,
@@ -73,18 +77,17 @@ func (*Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
 		return nil, fmt.Errorf("cost smaller than %d: %w", costMin,
 			pwhash.ErrCost)
 	}
-	var h hash.Hash
 	var sum []byte
 	// init the hash function
-	h = sha256.New()
+	h := sha256.New()
 	// write the initial input to the hash function
 	// h.Write never returns an error
 	h.Write(key)
 	h.Write(salt)
 	h.Write(key)
-	sum = h.Sum(nil)
+	sum = h.Sum(make([]byte, 0, sha256.Size))
 	// reset for next stage
-	h = sha256.New()
+	h.Reset()
 	h.Write(key)
 	h.Write(salt)
 	// repeatedly write the first 32 bytes of the initial sum depending on the
@@ -106,59 +109,27 @@ func (*Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
 	// store intermediate sum again
 	sum = h.Sum(sum[:0])
 	// re-init the hash function
-	h = sha256.New()
+	h.Reset()
 	// repeatedly write the key to the hash function depending on the key length
 	for n = 0; n < len(key); n++ {
 		h.Write(key)
 	}
-	// store the P bytes
-	pbuf := h.Sum(nil)
+	// store the P bytes, the digest repeated to the length of the key
+	pbuf := shacrypt.Sequence(h.Sum(nil), len(key))
 	// re-init the hash function
-	h = sha256.New()
-	for n = 0; n < int(16+sum[0]); n++ {
+	h.Reset()
+	for n = 0; n < 16+int(sum[0]); n++ {
 		h.Write(salt)
 	}
-	// store the S bytes
-	sbuf := h.Sum(nil)
+	// store the S bytes, the digest repeated to the length of the salt
+	sbuf := shacrypt.Sequence(h.Sum(nil), len(salt))
 	// run the rounds
-	for n = 0; n < int(cost); n++ {
-		h = sha256.New()
-		// alternate writing P bytes or most recent intermediate checksum
-		if n%2 != 0 {
-			recycle(h, pbuf, len(key))
-		} else {
-			h.Write(sum)
-		}
-		// write S bytes unless divisible by 3
-		if n%3 != 0 {
-			recycle(h, sbuf, len(salt))
-		}
-		// write P bytes unless divisible by 7
-		if n%7 != 0 {
-			recycle(h, pbuf, len(key))
-		}
-		// alternate writing P bytes or most recent intermediate checksum
-		if n%2 != 0 {
-			h.Write(sum)
-		} else {
-			recycle(h, pbuf, len(key))
-		}
-		sum = h.Sum(sum[:0])
+	sum, err := shacrypt.Rounds(ctx, h, sum, pbuf, sbuf, cost)
+	if err != nil {
+		return nil, err
 	}
 	// encode the output
-	var buf bytes.Buffer
-	// limit is number of bytes in checksum minus bytes encoded after the loop,
-	// divided by three since we write three bytes inside the loop. Increment by
-	// three since we write three times inside the loop.
-	for i := 0; i < (sha256.Size-5)/3; i += 3 {
-		b64crypt.EncodeBytes(&buf, sum[i], sum[i+10], sum[i+20])
-		b64crypt.EncodeBytes(&buf, sum[i+21], sum[i+1], sum[i+11])
-		b64crypt.EncodeBytes(&buf, sum[i+12], sum[i+22], sum[i+2])
-	}
-	b64crypt.EncodeBytes(&buf, sum[9], sum[19], sum[29])
-	b64crypt.EncodeBytes(&buf, 0, sum[31], sum[30])
-	// snip the trailing suffix to ignore the final fully zero six bits
-	return buf.Bytes()[:sha256.Size*4/3+1], nil
+	return permutation.Encode(sum), nil
 }
 
 // Parse the given hash string in its common encoded form.
@@ -193,6 +164,16 @@ func (*Function) Format(hash, salt []byte, cost uint) string {
 	return fmt.Sprintf("%srounds=%d$%s$%s", prefix, cost, salt, hash)
 }
 
+// Digest returns the raw digest bytes encoded in the given hash.
+func (*Function) Digest(hash []byte) ([]byte, error) {
+	digest, err := permutation.Decode(hash)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decode %s hash: %v: %w", ID, err,
+			pwhash.ErrParse)
+	}
+	return digest, nil
+}
+
 // ID returns the unique identification string of this hash function.
 func (*Function) ID() string {
 	return ID
@@ -208,3 +189,39 @@ func (*Function) DefaultCost() uint {
 func (*Function) GenerateSalt() ([]byte, error) {
 	return b64crypt.GenerateSalt(saltMaxLen)
 }
+
+// ValidateSalt checks that the given salt is of valid length and contains only
+// characters which are permitted in the encoded form of this function.
+func (*Function) ValidateSalt(salt []byte) error {
+	if len(salt) < 1 || len(salt) > saltMaxLen {
+		return fmt.Errorf("salt must be 1 to %d bytes: %w", saltMaxLen,
+			pwhash.ErrSaltLen)
+	}
+	if bytes.ContainsAny(salt, "$:\n") {
+		return fmt.Errorf("salt contains '$', ':' or newline: %w",
+			pwhash.ErrSaltCharset)
+	}
+	return nil
+}
+
+// Specificity returns pwhash.Specific, since the encoded form of this function
+// has an identifying prefix.
+func (*Function) Specificity() pwhash.Specificity {
+	return pwhash.Specific
+}
+
+// CostRange returns the minimum and maximum cost values accepted by Hash.
+func (*Function) CostRange() (uint, uint) {
+	return costMin, costMax
+}
+
+// Verdict returns a weak verdict, since sha256crypt is not memory-hard.
+func (*Function) Verdict() pwhash.Verdict {
+	return pwhash.Verdict{
+		Weak:   true,
+		Reason: "not memory-hard, so weaker than yescrypt against GPU attacks",
+		References: []string{
+			"https://manpages.debian.org/testing/libcrypt-dev/crypt.5.en.html#sha256crypt",
+		},
+	}
+}
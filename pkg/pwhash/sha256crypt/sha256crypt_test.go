@@ -2,44 +2,21 @@ package sha256crypt_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/pwhashtest"
 	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
 )
 
-type hashTestInput struct {
-	password string
-	salt     string
-	cost     uint
+func TestVectors(t *testing.T) {
+	pwhashtest.TestVectors(t, &sha256crypt.Function{}, "testdata/vectors.jsonl")
 }
 
-func TestHash(t *testing.T) {
-	var testCases = map[string]struct {
-		input  hashTestInput
-		expect string
-	}{
-		"mkpasswd 1": {
-			input:  hashTestInput{"foo", "IDRkfIy1SYTbgI6X", 1000},
-			expect: "KNHIuiRy7ZcBnFp0/OzMx0DkFoM6M2AFrdU../DzdU7",
-		},
-		"mkpasswd 2": {
-			input:  hashTestInput{"abiglongpassword", "7zOLT9IhFoUT6hgU", 9999},
-			expect: "2Kx5z3lnIZGhjzMd2UKKN9SVxQjLy3wd5x.X00uEoo6",
-		},
-	}
-	var c sha256crypt.Function
-	for name, tc := range testCases {
-		t.Run(name, func(tt *testing.T) {
-			result, err := c.Hash([]byte(tc.input.password), []byte(tc.input.salt),
-				tc.input.cost)
-			if err != nil {
-				tt.Fatal(err)
-			}
-			if !bytes.Equal(result, []byte(tc.expect)) {
-				tt.Fatalf("expected %s, got %s", tc.expect, string(result))
-			}
-		})
-	}
+func TestFunction(t *testing.T) {
+	pwhashtest.TestFunction(t, &sha256crypt.Function{})
 }
 
 type parseOutput struct {
@@ -84,3 +61,84 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+type formatTestInput struct {
+	hash string
+	salt string
+	cost uint
+}
+
+func TestFormat(t *testing.T) {
+	var testCases = map[string]struct {
+		input  formatTestInput
+		expect string
+	}{
+		"default rounds": {
+			input:  formatTestInput{"le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD", "GX7BopJZJxPc/KEK", 5000},
+			expect: `$5$rounds=5000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+		},
+		"custom rounds": {
+			input:  formatTestInput{"le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD", "GX7BopJZJxPc/KEK", 10000},
+			expect: `$5$rounds=10000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+		},
+	}
+	var c sha256crypt.Function
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			result := c.Format([]byte(tc.input.hash), []byte(tc.input.salt),
+				tc.input.cost)
+			if result != tc.expect {
+				tt.Fatalf("expected %s, got %s", tc.expect, result)
+			}
+		})
+	}
+}
+
+func TestValidateSalt(t *testing.T) {
+	var testCases = map[string]struct {
+		input  string
+		expect error
+	}{
+		"valid": {
+			input:  "GX7BopJZJxPc/KEK",
+			expect: nil,
+		},
+		"empty": {
+			input:  "",
+			expect: pwhash.ErrSaltLen,
+		},
+		"too long": {
+			input:  "GX7BopJZJxPc/KEKX",
+			expect: pwhash.ErrSaltLen,
+		},
+		"colon": {
+			input:  "GX7Bop:ZJxPc/KEK",
+			expect: pwhash.ErrSaltCharset,
+		},
+	}
+	var c sha256crypt.Function
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			err := c.ValidateSalt([]byte(tc.input))
+			if !errors.Is(err, tc.expect) {
+				tt.Fatalf("expected err %v, got %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func TestHashContext(t *testing.T) {
+	var c sha256crypt.Function
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.HashContext(ctx, []byte("foo"), []byte("GX7BopJZJxPc/KEK"),
+		c.DefaultCost())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected err %v, got %v", context.Canceled, err)
+	}
+}
+
+func BenchmarkHash(b *testing.B) {
+	pwhashtest.BenchmarkHash(b, &sha256crypt.Function{},
+		[]byte("GX7BopJZJxPc/KEK"))
+}
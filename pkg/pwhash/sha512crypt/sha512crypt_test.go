@@ -2,40 +2,21 @@ package sha512crypt_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/pwhashtest"
 	"github.com/smlx/hashy/pkg/pwhash/sha512crypt"
 )
 
-type hashTestInput struct {
-	password string
-	salt     string
-	cost     uint
+func TestVectors(t *testing.T) {
+	pwhashtest.TestVectors(t, &sha512crypt.Function{}, "testdata/vectors.jsonl")
 }
 
-func TestHash(t *testing.T) {
-	var testCases = map[string]struct {
-		input  hashTestInput
-		expect string
-	}{
-		"mkpasswd": {
-			input:  hashTestInput{"test", "rTnE1VTfjNYkoY0k", 1000},
-			expect: "k1YwHXQXAysWwIPpmQ2EvDjs62.Hqdh2yv8b0qbvR/.myAiOM5olqJdN.wvGk0zkIgGzSwIOIEKuEjX7OBOtX/",
-		},
-	}
-	var c sha512crypt.Function
-	for name, tc := range testCases {
-		t.Run(name, func(tt *testing.T) {
-			result, err := c.Hash([]byte(tc.input.password), []byte(tc.input.salt),
-				tc.input.cost)
-			if err != nil {
-				tt.Fatal(err)
-			}
-			if !bytes.Equal(result, []byte(tc.expect)) {
-				tt.Fatalf("expected %s, got %s", tc.expect, string(result))
-			}
-		})
-	}
+func TestFunction(t *testing.T) {
+	pwhashtest.TestFunction(t, &sha512crypt.Function{})
 }
 
 type parseOutput struct {
@@ -89,3 +70,84 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+type formatTestInput struct {
+	hash string
+	salt string
+	cost uint
+}
+
+func TestFormat(t *testing.T) {
+	var testCases = map[string]struct {
+		input  formatTestInput
+		expect string
+	}{
+		"default rounds": {
+			input:  formatTestInput{"aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1", "zrr5Kt7jpmLAHTeX", 5000},
+			expect: `$6$rounds=5000$zrr5Kt7jpmLAHTeX$aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`,
+		},
+		"custom rounds": {
+			input:  formatTestInput{"aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1", "zrr5Kt7jpmLAHTeX", 10000},
+			expect: `$6$rounds=10000$zrr5Kt7jpmLAHTeX$aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`,
+		},
+	}
+	var c sha512crypt.Function
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			result := c.Format([]byte(tc.input.hash), []byte(tc.input.salt),
+				tc.input.cost)
+			if result != tc.expect {
+				tt.Fatalf("expected %s, got %s", tc.expect, result)
+			}
+		})
+	}
+}
+
+func TestValidateSalt(t *testing.T) {
+	var testCases = map[string]struct {
+		input  string
+		expect error
+	}{
+		"valid": {
+			input:  "zrr5Kt7jpmLAHTeX",
+			expect: nil,
+		},
+		"empty": {
+			input:  "",
+			expect: pwhash.ErrSaltLen,
+		},
+		"too long": {
+			input:  "zrr5Kt7jpmLAHTeXX",
+			expect: pwhash.ErrSaltLen,
+		},
+		"newline": {
+			input:  "zrr5Kt7\npmLAHTeX",
+			expect: pwhash.ErrSaltCharset,
+		},
+	}
+	var c sha512crypt.Function
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			err := c.ValidateSalt([]byte(tc.input))
+			if !errors.Is(err, tc.expect) {
+				tt.Fatalf("expected err %v, got %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func TestHashContext(t *testing.T) {
+	var c sha512crypt.Function
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.HashContext(ctx, []byte("foo"), []byte("zrr5Kt7jpmLAHTeX"),
+		c.DefaultCost())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected err %v, got %v", context.Canceled, err)
+	}
+}
+
+func BenchmarkHash(b *testing.B) {
+	pwhashtest.BenchmarkHash(b, &sha512crypt.Function{},
+		[]byte("GX7BopJZJxPc/KEK"))
+}
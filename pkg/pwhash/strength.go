@@ -0,0 +1,123 @@
+package pwhash
+
+import (
+	"fmt"
+	"math"
+)
+
+// Verdict is an assessment of the known weaknesses of a Function.
+type Verdict struct {
+	// Weak is true if the function is not best practice for storing
+	// passwords.
+	Weak bool
+	// Reason briefly describes the weakness.
+	Reason string
+	// References are links to documentation or CVEs describing the weakness.
+	References []string
+}
+
+// The Assessor interface may be implemented by a Function to report its known
+// weaknesses.
+type Assessor interface {
+	// Verdict returns an assessment of the known weaknesses of the function.
+	Verdict() Verdict
+}
+
+// The ProtectingSalter interface may be implemented by a Function whose
+// parsed salt contains parameters or salts which don't protect the final
+// hash, such as the inner salt of a wrapped hash.
+type ProtectingSalter interface {
+	// ProtectingSalt returns the part of the given parsed salt which salts the
+	// final hash.
+	ProtectingSalt(salt []byte) ([]byte, error)
+}
+
+// Strength is a report on the strength of an encoded hash.
+type Strength struct {
+	// SaltBits is an upper bound on the entropy of the salt in bits, estimated
+	// from its length and the smallest common character set containing it.
+	SaltBits float64
+	// Cost is the cost of the hash.
+	Cost uint
+	// DefaultCost is the default cost of the function, or zero if the function
+	// has no cost parameter.
+	DefaultCost uint
+	// Verdict is the assessment of the known weaknesses of the function, or nil
+	// if the function does not implement Assessor.
+	Verdict *Verdict
+}
+
+// CostRatio returns the cost of the hash relative to the default cost of the
+// function, or zero if the function has no cost parameter.
+func (s *Strength) CostRatio() float64 {
+	if s.DefaultCost == 0 {
+		return 0
+	}
+	return float64(s.Cost) / float64(s.DefaultCost)
+}
+
+// charsets are common salt character sets, in order of size.
+var charsets = []struct {
+	size  int
+	match func(c byte) bool
+}{
+	{10, func(c byte) bool { return '0' <= c && c <= '9' }},
+	{16, func(c byte) bool { return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' }},
+	{16, func(c byte) bool { return '0' <= c && c <= '9' || 'A' <= c && c <= 'F' }},
+	{64, func(c byte) bool {
+		return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' ||
+			'A' <= c && c <= 'Z' || c == '.' || c == '/'
+	}},
+	{64, func(c byte) bool {
+		return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' ||
+			'A' <= c && c <= 'Z' || c == '+' || c == '/' || c == '='
+	}},
+	{95, func(c byte) bool { return ' ' <= c && c <= '~' }},
+}
+
+// SaltBits returns an upper bound on the entropy of the given salt in bits,
+// estimated from its length and the smallest common character set containing
+// it: decimal digits, hex, the crypt or RFC4648 base64 alphabets, or printable
+// ASCII. Salts containing other bytes are assumed to be random bytes.
+func SaltBits(salt []byte) float64 {
+	size := 256
+	for _, cs := range charsets {
+		match := true
+		for _, c := range salt {
+			if !cs.match(c) {
+				match = false
+				break
+			}
+		}
+		if match {
+			size = cs.size
+			break
+		}
+	}
+	return float64(len(salt)) * math.Log2(float64(size))
+}
+
+// Assess returns a report on the strength of the given encoded hash of the
+// given function, ignoring any pepper prefix. If the function implements
+// ProtectingSalter only the protecting part of the salt is assessed.
+func Assess(f Function, encodedHash []byte) (*Strength, error) {
+	_, salt, cost, err := f.Parse(stripPepper(encodedHash))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse %s hash: %w", f.ID(), err)
+	}
+	if ps, ok := f.(ProtectingSalter); ok {
+		if salt, err = ps.ProtectingSalt(salt); err != nil {
+			return nil, fmt.Errorf("couldn't split %s salt: %w", f.ID(), err)
+		}
+	}
+	s := Strength{
+		SaltBits:    SaltBits(salt),
+		Cost:        cost,
+		DefaultCost: f.DefaultCost(),
+	}
+	if a, ok := f.(Assessor); ok {
+		v := a.Verdict()
+		s.Verdict = &v
+	}
+	return &s, nil
+}
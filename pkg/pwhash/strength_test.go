@@ -0,0 +1,106 @@
+package pwhash_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+	"github.com/smlx/hashy/pkg/pwhash/wrapped"
+)
+
+func TestSaltBits(t *testing.T) {
+	var testCases = map[string]struct {
+		salt   string
+		expect float64
+	}{
+		"empty":     {salt: "", expect: 0},
+		"digits":    {salt: "28772684", expect: 8 * math.Log2(10)},
+		"hex":       {salt: "deadbeef", expect: 32},
+		"b64crypt":  {salt: "GX7BopJZJxPc/KEK", expect: 96},
+		"base64":    {salt: "GX7BopJZJxPc+KE=", expect: 96},
+		"printable": {salt: "salt:with:colons", expect: 16 * math.Log2(95)},
+		"bytes":     {salt: "\x00\xff", expect: 16},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			if bits := pwhash.SaltBits([]byte(tc.salt)); bits != tc.expect {
+				tt.Fatalf("expected %v, got %v", tc.expect, bits)
+			}
+		})
+	}
+}
+
+func TestAssess(t *testing.T) {
+	var testCases = map[string]struct {
+		function    pwhash.Function
+		encodedHash string
+		expectBits  float64
+		expectRatio float64
+		expectWeak  bool
+		expectErr   error
+	}{
+		"md5crypt": {
+			function:    &md5crypt.Function{},
+			encodedHash: `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			expectBits:  8 * math.Log2(10),
+			expectWeak:  true,
+		},
+		"sha256crypt double cost": {
+			function:    &sha256crypt.Function{},
+			encodedHash: `$5$rounds=10000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			expectBits:  96,
+			expectRatio: 2,
+			expectWeak:  true,
+		},
+		"peppered": {
+			function:    &md5crypt.Function{},
+			encodedHash: `$pepper$1$$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			expectBits:  8 * math.Log2(10),
+			expectWeak:  true,
+		},
+		"unsalted": {
+			function:    &mariadboldpassword.Function{},
+			encodedHash: `7196759210defdc0`,
+			expectWeak:  true,
+		},
+		"wrapped": {
+			function: wrapped.New(&md5crypt.Function{}, &sha256crypt.Function{}),
+			encodedHash: `$wrapped$sha256crypt$md5crypt$0$28772684$` +
+				`$5$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			// only the outer salt protects the hash
+			expectBits:  96,
+			expectRatio: 1,
+			expectWeak:  true,
+		},
+		"parse error": {
+			function:    &md5crypt.Function{},
+			encodedHash: `7196759210defdc0`,
+			expectErr:   pwhash.ErrParse,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			s, err := pwhash.Assess(tc.function, []byte(tc.encodedHash))
+			if !errors.Is(err, tc.expectErr) {
+				tt.Fatalf("expected err %v, got %v", tc.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if math.Abs(s.SaltBits-tc.expectBits) > 1e-9 {
+				tt.Fatalf("expected salt bits %v, got %v", tc.expectBits, s.SaltBits)
+			}
+			if ratio := s.CostRatio(); ratio != tc.expectRatio {
+				tt.Fatalf("expected cost ratio %v, got %v", tc.expectRatio, ratio)
+			}
+			if s.Verdict == nil || s.Verdict.Weak != tc.expectWeak ||
+				len(s.Verdict.References) == 0 {
+				tt.Fatalf("unexpected verdict %+v", s.Verdict)
+			}
+		})
+	}
+}
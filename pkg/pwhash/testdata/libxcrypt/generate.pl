@@ -0,0 +1,78 @@
+#!/usr/bin/env perl
+#
+# Generate test vector corpora using the system crypt(3), which must be
+# provided by libxcrypt. Run from this directory:
+#
+#   perl generate.pl
+#
+# The output files are in the JSON lines format read by pwhashtest.ReadVectors.
+# The output is deterministic for a given libxcrypt version, so regenerating
+# the corpora should produce no diff.
+use strict;
+use warnings;
+use JSON::PP;
+
+srand(1);
+
+my $json = JSON::PP->new->allow_nonref;
+my @b64 = ('.', '/', '0' .. '9', 'A' .. 'Z', 'a' .. 'z');
+
+sub salt {
+	my ($len) = @_;
+	return join '', map { $b64[int(rand(@b64))] } 1 .. $len;
+}
+
+# password returns a password which crosses the block boundaries of the
+# algorithms and sometimes contains bytes outside of ASCII.
+sub password {
+	my @lens = (0 .. 80, 127, 128, 129, 200);
+	my $len = $lens[int(rand(@lens))];
+	my $high = rand() < 0.25;
+	return join '', map {
+		chr($high ? 1 + int(rand(255)) : 32 + int(rand(95)))
+	} 1 .. $len;
+}
+
+# corpus writes count vectors for the named function. The setting function
+# returns the crypt(3) setting, the salt and the cost of each vector.
+sub corpus {
+	my ($name, $count, $setting) = @_;
+	open(my $fh, '>', "$name.jsonl") or die "couldn't open $name.jsonl: $!";
+	for (1 .. $count) {
+		my $password = password();
+		my ($s, $salt, $cost) = $setting->();
+		my $hash = crypt($password, $s);
+		die "crypt failed for $name" if !defined($hash) || $hash =~ /^\*/;
+		# passwords outside of ASCII may not be valid UTF-8
+		my $pw = $password =~ /[^\x00-\x7f]/
+			? '"password_hex":' . $json->encode(unpack('H*', $password))
+			: '"password":' . $json->encode($password);
+		printf $fh qq({"function":%s,%s,"salt":%s,"cost":%d,"hash":%s}\n),
+			$json->encode($name), $pw, $json->encode($salt), $cost,
+			$json->encode($hash);
+	}
+	close($fh);
+}
+
+corpus('md5crypt', 64, sub {
+	my $salt = salt(1 + int(rand(8)));
+	("\$1\$$salt", $salt, 0);
+});
+corpus('sha1crypt', 64, sub {
+	# use single digit rounds for some vectors
+	my $rounds = rand() < 0.25 ? 1 + int(rand(9)) : 1 + int(rand(2000));
+	my $salt = salt(1 + int(rand(64)));
+	("\$sha1\$$rounds\$$salt", $salt, $rounds);
+});
+for my $f (['sha256crypt', 5], ['sha512crypt', 6]) {
+	my ($name, $id) = @$f;
+	corpus($name, 64, sub {
+		my $salt = salt(1 + int(rand(16)));
+		# use the default (omitted) rounds for some vectors
+		if (rand() < 0.25) {
+			return ("\$$id\$$salt", $salt, 5000);
+		}
+		my $rounds = 1000 + int(rand(2000));
+		("\$$id\$rounds=$rounds\$$salt", $salt, $rounds);
+	});
+}
@@ -0,0 +1,158 @@
+package pwhash
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"math"
+)
+
+// DefaultMaxCostFactor is the multiple of a function's default cost which is
+// permitted by VerifyOptions, unless overridden by VerifyOptions.MaxCost.
+const DefaultMaxCostFactor = 16
+
+// ErrCostLimit is returned when the cost of an encoded hash exceeds the
+// maximum permitted cost. It wraps ErrCost.
+var ErrCostLimit = fmt.Errorf("cost exceeds permitted maximum: %w", ErrCost)
+
+// VerifyOptions control the verification of untrusted encoded hashes.
+type VerifyOptions struct {
+	// MaxCost maps function IDs to the maximum cost permitted in an encoded
+	// hash. Functions not in the map are permitted DefaultMaxCostFactor times
+	// their default cost, unless NoDefaultMaxCost is set.
+	MaxCost map[string]uint
+	// NoDefaultMaxCost permits any cost for functions not in MaxCost. It is
+	// intended for local verification of hashes from a trusted source, which
+	// may legitimately use a much higher cost than the default.
+	NoDefaultMaxCost bool
+	// Peppers are used to verify peppered hashes. A peppered hash can only be
+	// verified if the pepper with the matching ID is available.
+	Peppers []Pepper
+}
+
+// MaxCostFor returns the maximum cost permitted for the given function. It
+// may be called on a nil *VerifyOptions, which uses the defaults.
+func (o *VerifyOptions) MaxCostFor(f Function) uint {
+	if o != nil {
+		if maxCost, ok := o.MaxCost[f.ID()]; ok {
+			return maxCost
+		}
+		if o.NoDefaultMaxCost {
+			return math.MaxUint
+		}
+	}
+	return f.DefaultCost() * DefaultMaxCostFactor
+}
+
+// CheckCost returns an error wrapping ErrCostLimit if the given cost is
+// larger than the maximum permitted for the given function. It may be called
+// on a nil *VerifyOptions, which uses the defaults.
+func (o *VerifyOptions) CheckCost(f Function, cost uint) error {
+	if maxCost := o.MaxCostFor(f); cost > maxCost {
+		return fmt.Errorf("%s cost %d larger than %d: %w", f.ID(), cost, maxCost,
+			ErrCostLimit)
+	}
+	return nil
+}
+
+// The Wrapper interface is implemented by Functions which hash the output of
+// an inner function, so that the cost of the inner function can be checked
+// against VerifyOptions before the password is hashed.
+type Wrapper interface {
+	// Inner returns the inner function and the inner cost encoded in the given
+	// parsed salt.
+	Inner(salt []byte) (Function, uint, error)
+}
+
+// Prepared is an encoded hash which has been parsed and checked against
+// VerifyOptions, so that passwords can be verified against it without parsing
+// it again.
+type Prepared struct {
+	// Function is the function used to parse the encoded hash.
+	Function Function
+	// Hash, Salt and Cost are the parsed components of the encoded hash.
+	Hash []byte
+	Salt []byte
+	Cost uint
+	// Pepper is applied to passwords before they are hashed, or is nil if the
+	// hash is not peppered.
+	Pepper *Pepper
+}
+
+// Prepare does the work of Verify which doesn't depend on the password: it
+// parses the given encoded hash using f, finds the pepper with the matching
+// ID in opts if the hash is peppered, and checks the parsed cost against
+// opts, including the inner cost if f implements Wrapper. If opts is nil the
+// defaults are used.
+func Prepare(f Function, encodedHash []byte,
+	opts *VerifyOptions) (*Prepared, error) {
+	var pepper *Pepper
+	if id, inner, ok := SplitPepper(encodedHash); ok {
+		var peppers []Pepper
+		if opts != nil {
+			peppers = opts.Peppers
+		}
+		var err error
+		if pepper, err = findPepper(peppers, id); err != nil {
+			return nil, err
+		}
+		encodedHash = inner
+	}
+	hash, salt, cost, err := f.Parse(encodedHash)
+	if err != nil {
+		return nil, err
+	}
+	if err = opts.CheckCost(f, cost); err != nil {
+		return nil, err
+	}
+	if w, ok := f.(Wrapper); ok {
+		inner, innerCost, err := w.Inner(salt)
+		if err != nil {
+			return nil, err
+		}
+		if err = opts.CheckCost(inner, innerCost); err != nil {
+			return nil, err
+		}
+	}
+	return &Prepared{Function: f, Hash: hash, Salt: salt, Cost: cost,
+		Pepper: pepper}, nil
+}
+
+// Key returns the key which is hashed to verify the given password: the
+// password with the pepper applied if the hash is peppered.
+func (p *Prepared) Key(password []byte) []byte {
+	if p.Pepper != nil {
+		return p.Pepper.Apply(password)
+	}
+	return password
+}
+
+// Verify reports whether the given password matches the prepared hash.
+func (p *Prepared) Verify(ctx context.Context, password []byte) (bool,
+	error) {
+	calculatedHash, err := HashContext(ctx, p.Function, p.Key(password), p.Salt,
+		p.Cost)
+	if err != nil {
+		return false, err
+	}
+	return p.Match(calculatedHash), nil
+}
+
+// Match reports whether the given hash, calculated from the key of a
+// password, matches the prepared hash.
+func (p *Prepared) Match(calculatedHash []byte) bool {
+	return subtle.ConstantTimeCompare(p.Hash, calculatedHash) == 1
+}
+
+// Verify parses the given encoded hash using f and reports whether the given
+// password matches it. The parsed cost is checked against opts before the
+// password is hashed. If the hash is peppered, the pepper with the matching ID
+// in opts is applied to the password. If opts is nil the defaults are used.
+func Verify(ctx context.Context, f Function, encodedHash, password []byte,
+	opts *VerifyOptions) (bool, error) {
+	p, err := Prepare(f, encodedHash, opts)
+	if err != nil {
+		return false, err
+	}
+	return p.Verify(ctx, password)
+}
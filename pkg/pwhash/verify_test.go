@@ -0,0 +1,106 @@
+package pwhash_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+)
+
+func TestVerify(t *testing.T) {
+	var testCases = map[string]struct {
+		function    pwhash.Function
+		encodedHash string
+		password    string
+		opts        *pwhash.VerifyOptions
+		expect      bool
+		expectErr   error
+	}{
+		"match": {
+			function:    &md5crypt.Function{},
+			encodedHash: `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			password:    "hashcat",
+			expect:      true,
+		},
+		"no match": {
+			function:    &md5crypt.Function{},
+			encodedHash: `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			password:    "notthepassword",
+			expect:      false,
+		},
+		"parse error": {
+			function:    &md5crypt.Function{},
+			encodedHash: `$5$rounds=5000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			password:    "hashcat",
+			expectErr:   pwhash.ErrParse,
+		},
+		"default cost": {
+			function:    &sha256crypt.Function{},
+			encodedHash: `$5$rounds=5000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			password:    "hashcat",
+			expect:      true,
+		},
+		"default max cost exceeded": {
+			function:    &sha256crypt.Function{},
+			encodedHash: `$5$rounds=999999999$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			password:    "hashcat",
+			expectErr:   pwhash.ErrCostLimit,
+		},
+		"no default max cost": {
+			function:    &sha256crypt.Function{},
+			encodedHash: `$5$rounds=100000$GX7BopJZJxPc/KEK$B0.otSf8vrLEEGD/pM4aaZMj/gpn3R9yyoAXvNAFQY/`,
+			password:    "hashcat",
+			opts:        &pwhash.VerifyOptions{NoDefaultMaxCost: true},
+			expect:      true,
+		},
+		"max cost with no default": {
+			function:    &sha256crypt.Function{},
+			encodedHash: `$5$rounds=100000$GX7BopJZJxPc/KEK$B0.otSf8vrLEEGD/pM4aaZMj/gpn3R9yyoAXvNAFQY/`,
+			password:    "hashcat",
+			opts: &pwhash.VerifyOptions{
+				MaxCost:          map[string]uint{sha256crypt.ID: 80000},
+				NoDefaultMaxCost: true,
+			},
+			expectErr: pwhash.ErrCostLimit,
+		},
+		"custom max cost exceeded": {
+			function:    &sha256crypt.Function{},
+			encodedHash: `$5$rounds=5000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			password:    "hashcat",
+			opts: &pwhash.VerifyOptions{
+				MaxCost: map[string]uint{sha256crypt.ID: 4999},
+			},
+			expectErr: pwhash.ErrCost,
+		},
+		"unknown pepper": {
+			function:    &md5crypt.Function{},
+			encodedHash: `$pepper$2$$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			password:    "hashcat",
+			opts: &pwhash.VerifyOptions{
+				Peppers: []pwhash.Pepper{{ID: "1", Key: []byte("key")}},
+			},
+			expectErr: pwhash.ErrPepper,
+		},
+		"missing pepper": {
+			function:    &md5crypt.Function{},
+			encodedHash: `$pepper$1$$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			password:    "hashcat",
+			expectErr:   pwhash.ErrPepper,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			match, err := pwhash.Verify(context.Background(), tc.function,
+				[]byte(tc.encodedHash), []byte(tc.password), tc.opts)
+			if !errors.Is(err, tc.expectErr) {
+				tt.Fatalf("expected err %v, got %v", tc.expectErr, err)
+			}
+			if match != tc.expect {
+				tt.Fatalf("expected match %v, got %v", tc.expect, match)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Package wrapped implements a pwhash.Function which composes two other
+// functions by hashing the encoded output of an inner function with an outer
+// function. This allows legacy hashes to be upgraded without knowing the
+// password, by wrapping the existing encoded hash inside a stronger one.
+//
+// The encoded form of a wrapped hash is:
+//
+//	$wrapped$<outer ID>$<inner ID>$<inner cost>$<inner salt>$<outer encoded hash>
+//
+// The salt used by this function contains the inner cost, inner salt and
+// outer salt, separated by '$'.
+package wrapped
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+)
+
+const (
+	// prefix is the identifier of the wrapped encoded form
+	prefix = "$wrapped$"
+	// saltSep separates the components of the salt
+	saltSep = "$"
+)
+
+// parseRegex is used to parse the formatted hash into its component parts.
+var parseRegex = regexp.MustCompile(
+	`^\$wrapped\$(?P<outer>[^$]+)\$(?P<inner>[^$]+)\$(?P<innercost>[0-9]+)\$` +
+		`(?P<innersalt>[^$]*)\$(?P<outerhash>.+)$`)
+
+// Function implements the pwhash.Function interface for the composition of
+// an inner and outer function.
+type Function struct {
+	inner pwhash.Function
+	outer pwhash.Function
+}
+
+// New returns a Function which calculates outer(inner(key)).
+func New(inner, outer pwhash.Function) *Function {
+	return &Function{inner: inner, outer: outer}
+}
+
+// joinSalt returns the salt of this function composed of the given parts.
+func joinSalt(innerCost uint, innerSalt, outerSalt []byte) []byte {
+	return bytes.Join([][]byte{
+		[]byte(strconv.FormatUint(uint64(innerCost), 10)), innerSalt, outerSalt,
+	}, []byte(saltSep))
+}
+
+// splitSalt returns the parts of the given salt of this function.
+func splitSalt(salt []byte) (uint, []byte, []byte, error) {
+	parts := bytes.SplitN(salt, []byte(saltSep), 3)
+	if len(parts) != 3 {
+		return 0, nil, nil, fmt.Errorf("salt missing inner cost or salt: %w",
+			pwhash.ErrSaltCharset)
+	}
+	innerCost, err := strconv.ParseUint(string(parts[0]), 10, 64)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("couldn't parse inner cost in salt: %w",
+			pwhash.ErrSaltCharset)
+	}
+	return uint(innerCost), parts[1], parts[2], nil
+}
+
+// Hash returns the hash of the given key. The key is hashed with the inner
+// function using the inner cost and salt, and the encoded inner hash is then
+// hashed with the outer function using the given cost and the outer salt.
+func (f *Function) Hash(key, salt []byte, cost uint) ([]byte, error) {
+	return f.HashContext(context.Background(), key, salt, cost)
+}
+
+// HashContext is like Hash, but returns an error wrapping ctx.Err() if ctx is
+// done before the hash is calculated.
+func (f *Function) HashContext(ctx context.Context, key, salt []byte,
+	cost uint) ([]byte, error) {
+	innerCost, innerSalt, outerSalt, err := splitSalt(salt)
+	if err != nil {
+		return nil, err
+	}
+	innerHash, err := pwhash.HashContext(ctx, f.inner, key, innerSalt,
+		innerCost)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't hash using %s: %w", f.inner.ID(), err)
+	}
+	innerEncoded := f.inner.Format(innerHash, innerSalt, innerCost)
+	return f.hashInner(ctx, []byte(innerEncoded), outerSalt, cost)
+}
+
+// hashInner hashes the given encoded inner hash with the outer function.
+func (f *Function) hashInner(ctx context.Context, innerEncoded,
+	outerSalt []byte, cost uint) ([]byte, error) {
+	outerHash, err := pwhash.HashContext(ctx, f.outer, innerEncoded, outerSalt,
+		cost)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't hash using %s: %w", f.outer.ID(), err)
+	}
+	return outerHash, nil
+}
+
+// Parse the given hash string in its common encoded form.
+func (f *Function) Parse(encodedHash []byte) ([]byte, []byte, uint, error) {
+	matches := parseRegex.FindSubmatch(encodedHash)
+	if len(matches) < 6 ||
+		string(matches[parseRegex.SubexpIndex("outer")]) != f.outer.ID() ||
+		string(matches[parseRegex.SubexpIndex("inner")]) != f.inner.ID() {
+		return nil, nil, 0, fmt.Errorf("couldn't parse %s format: %w", f.ID(),
+			pwhash.ErrParse)
+	}
+	innerCost, err := strconv.ParseUint(
+		string(matches[parseRegex.SubexpIndex("innercost")]), 10, 64)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("couldn't parse %s inner cost: %w", f.ID(),
+			pwhash.ErrParse)
+	}
+	hash, outerSalt, cost, err := f.outer.Parse(
+		matches[parseRegex.SubexpIndex("outerhash")])
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("couldn't parse %s outer hash: %w", f.ID(),
+			err)
+	}
+	innerSalt := matches[parseRegex.SubexpIndex("innersalt")]
+	return hash, joinSalt(uint(innerCost), innerSalt, outerSalt), cost, nil
+}
+
+// Format the given parameters into the common "password hash" form.
+func (f *Function) Format(hash, salt []byte, cost uint) string {
+	// Format cannot return an error, and the salt is validated by Hash
+	innerCost, innerSalt, outerSalt, _ := splitSalt(salt)
+	return fmt.Sprintf("%s%s$%s$%d$%s$%s", prefix, f.outer.ID(), f.inner.ID(),
+		innerCost, innerSalt, f.outer.Format(hash, outerSalt, cost))
+}
+
+// ID returns the unique identification string of this hash function, which
+// is of the form outer(inner).
+func (f *Function) ID() string {
+	return fmt.Sprintf("%s(%s)", f.outer.ID(), f.inner.ID())
+}
+
+// DefaultCost returns the default cost of the outer function.
+func (f *Function) DefaultCost() uint {
+	return f.outer.DefaultCost()
+}
+
+// GenerateSalt returns a salt composed of the default cost of the inner
+// function and salts generated by the inner and outer functions.
+func (f *Function) GenerateSalt() ([]byte, error) {
+	innerSalt, err := f.inner.GenerateSalt()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate %s salt: %v", f.inner.ID(), err)
+	}
+	outerSalt, err := f.outer.GenerateSalt()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate %s salt: %v", f.outer.ID(), err)
+	}
+	return joinSalt(f.inner.DefaultCost(), innerSalt, outerSalt), nil
+}
+
+// ValidateSalt checks that the given salt is composed of an inner cost and
+// salts which are valid for the inner and outer functions.
+func (f *Function) ValidateSalt(salt []byte) error {
+	_, innerSalt, outerSalt, err := splitSalt(salt)
+	if err != nil {
+		return err
+	}
+	if err = pwhash.ValidateSalt(f.inner, innerSalt); err != nil {
+		return fmt.Errorf("invalid %s salt: %w", f.inner.ID(), err)
+	}
+	if err = pwhash.ValidateSalt(f.outer, outerSalt); err != nil {
+		return fmt.Errorf("invalid %s salt: %w", f.outer.ID(), err)
+	}
+	return nil
+}
+
+// Inner returns the inner function and the inner cost encoded in the given
+// salt, so that the inner cost of untrusted hashes can be checked by
+// pwhash.Prepare.
+func (f *Function) Inner(salt []byte) (pwhash.Function, uint, error) {
+	innerCost, _, _, err := splitSalt(salt)
+	if err != nil {
+		return nil, 0, err
+	}
+	return f.inner, innerCost, nil
+}
+
+// ProtectingSalt returns the outer salt from the given salt, since the inner
+// salt is visible to anyone who can see the wrapped hash and only the outer
+// salt protects it.
+func (*Function) ProtectingSalt(salt []byte) ([]byte, error) {
+	_, _, outerSalt, err := splitSalt(salt)
+	return outerSalt, err
+}
+
+// Specificity returns pwhash.Specific, since the encoded form of this function
+// has an identifying prefix.
+func (*Function) Specificity() pwhash.Specificity {
+	return pwhash.Specific
+}
+
+// Wrap returns the given encoded hash of the inner function wrapped by the
+// outer function, without requiring the password. If outerSalt is nil a salt
+// is generated, and if cost is zero the default cost of the outer function is
+// used. The inner cost is not limited, since the hashes being wrapped are
+// trusted.
+func (f *Function) Wrap(ctx context.Context, innerEncoded, outerSalt []byte,
+	cost uint) (string, error) {
+	innerHash, innerSalt, innerCost, err := f.inner.Parse(innerEncoded)
+	if err != nil {
+		return "", err
+	}
+	if outerSalt == nil {
+		outerSalt, err = f.outer.GenerateSalt()
+		if err != nil {
+			return "", fmt.Errorf("couldn't generate %s salt: %v", f.outer.ID(),
+				err)
+		}
+	}
+	if cost == 0 {
+		cost = f.outer.DefaultCost()
+	}
+	// re-format the inner hash so that it is canonical
+	canonical := f.inner.Format(innerHash, innerSalt, innerCost)
+	outerHash, err := f.hashInner(ctx, []byte(canonical), outerSalt, cost)
+	if err != nil {
+		return "", err
+	}
+	return f.Format(outerHash, joinSalt(innerCost, innerSalt, outerSalt), cost),
+		nil
+}
+
+// Verdict returns the verdict of the outer function, which protects the inner
+// hash. If the outer function does not implement pwhash.Assessor, the verdict
+// is not weak.
+func (f *Function) Verdict() pwhash.Verdict {
+	if a, ok := f.outer.(pwhash.Assessor); ok {
+		v := a.Verdict()
+		if v.Reason != "" {
+			v.Reason = fmt.Sprintf("%s: %s", f.outer.ID(), v.Reason)
+		}
+		return v
+	}
+	return pwhash.Verdict{}
+}
@@ -0,0 +1,275 @@
+package wrapped_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/pwhash"
+	"github.com/smlx/hashy/pkg/pwhash/mariadboldpassword"
+	"github.com/smlx/hashy/pkg/pwhash/md5crypt"
+	"github.com/smlx/hashy/pkg/pwhash/pwhashtest"
+	"github.com/smlx/hashy/pkg/pwhash/sha256crypt"
+	"github.com/smlx/hashy/pkg/pwhash/sha512crypt"
+	"github.com/smlx/hashy/pkg/pwhash/wrapped"
+)
+
+func TestHash(t *testing.T) {
+	var testCases = map[string]struct {
+		inner     pwhash.Function
+		outer     pwhash.Function
+		innerSalt string
+		outerSalt string
+		cost      uint
+	}{
+		"sha512crypt(md5crypt)": {
+			inner:     &md5crypt.Function{},
+			outer:     &sha512crypt.Function{},
+			innerSalt: "28772684",
+			outerSalt: "zrr5Kt7jpmLAHTeX",
+			cost:      5000,
+		},
+		"sha256crypt(mariaDBOldPassword)": {
+			inner:     &mariadboldpassword.Function{},
+			outer:     &sha256crypt.Function{},
+			innerSalt: "",
+			outerSalt: "GX7BopJZJxPc/KEK",
+			cost:      5000,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			f := wrapped.New(tc.inner, tc.outer)
+			if f.ID() != name {
+				tt.Fatalf("expected ID %s, got %s", name, f.ID())
+			}
+			salt := "0$" + tc.innerSalt + "$" + tc.outerSalt
+			result, err := f.Hash([]byte("hashcat"), []byte(salt), tc.cost)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			// calculate the expected hash from the encoded inner hash
+			innerHash, err := tc.inner.Hash([]byte("hashcat"),
+				[]byte(tc.innerSalt), 0)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			innerEncoded := tc.inner.Format(innerHash, []byte(tc.innerSalt), 0)
+			expect, err := tc.outer.Hash([]byte(innerEncoded),
+				[]byte(tc.outerSalt), tc.cost)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if !bytes.Equal(result, expect) {
+				tt.Fatalf("expected %s, got %s", expect, result)
+			}
+		})
+	}
+}
+
+func TestVectors(t *testing.T) {
+	for _, f := range []pwhash.Function{
+		wrapped.New(&md5crypt.Function{}, &sha512crypt.Function{}),
+		wrapped.New(&mariadboldpassword.Function{}, &sha256crypt.Function{}),
+	} {
+		pwhashtest.TestVectors(t, f, "testdata/vectors.jsonl")
+	}
+}
+
+func TestFunction(t *testing.T) {
+	for _, f := range []pwhash.Function{
+		wrapped.New(&md5crypt.Function{}, &sha512crypt.Function{}),
+		wrapped.New(&sha256crypt.Function{}, &sha512crypt.Function{}),
+		wrapped.New(&mariadboldpassword.Function{}, &sha256crypt.Function{}),
+	} {
+		t.Run(f.ID(), func(tt *testing.T) {
+			pwhashtest.TestFunction(tt, f)
+		})
+	}
+}
+
+type parseOutput struct {
+	hash []byte
+	salt []byte
+	cost uint
+	err  error
+}
+
+func TestParse(t *testing.T) {
+	f := wrapped.New(&md5crypt.Function{}, &sha512crypt.Function{})
+	var testCases = map[string]struct {
+		input  string
+		expect parseOutput
+	}{
+		"valid": {
+			input: `$wrapped$sha512crypt$md5crypt$0$28772684$` +
+				`$6$rounds=10000$zrr5Kt7jpmLAHTeX$aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`,
+			expect: parseOutput{
+				hash: []byte(`aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`),
+				salt: []byte(`0$28772684$zrr5Kt7jpmLAHTeX`),
+				cost: 10000,
+			},
+		},
+		"wrong inner": {
+			input: `$wrapped$sha512crypt$sha256crypt$0$28772684$` +
+				`$6$zrr5Kt7jpmLAHTeX$aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`,
+			expect: parseOutput{err: pwhash.ErrParse},
+		},
+		"invalid outer": {
+			input:  `$wrapped$sha512crypt$md5crypt$0$28772684$$6$zrr5Kt7jpmLAHTeX$`,
+			expect: parseOutput{err: pwhash.ErrParse},
+		},
+		"large inner cost": {
+			input: `$wrapped$sha512crypt$md5crypt$1$28772684$` +
+				`$6$zrr5Kt7jpmLAHTeX$aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`,
+			expect: parseOutput{
+				hash: []byte(`aMx8qDeBX2KIWFjZ1Fp2/jVE3E07/JnBKqxA9CjbyChKMn3LFaYSnypRmhJY8rgE/Xj5Br6yCcx4xH2tH0QAq1`),
+				salt: []byte(`1$28772684$zrr5Kt7jpmLAHTeX`),
+				cost: 5000,
+			},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			hash, salt, cost, err := f.Parse([]byte(tc.input))
+			if !errors.Is(err, tc.expect.err) {
+				tt.Fatalf("expected err %v, got %v", tc.expect.err, err)
+			}
+			if !bytes.Equal(tc.expect.hash, hash) {
+				tt.Fatalf("expected hash %s, got %s", tc.expect.hash, hash)
+			}
+			if !bytes.Equal(tc.expect.salt, salt) {
+				tt.Fatalf("expected salt %s, got %s", tc.expect.salt, salt)
+			}
+			if tc.expect.cost != cost {
+				tt.Fatalf("expected cost %v, got %v", tc.expect.cost, cost)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	var testCases = map[string]struct {
+		inner        pwhash.Function
+		outer        pwhash.Function
+		innerEncoded string
+		password     string
+	}{
+		"md5crypt": {
+			inner:        &md5crypt.Function{},
+			outer:        &sha512crypt.Function{},
+			innerEncoded: `$1$28772684$iEwNOgGugqO9.bIz5sk8k/`,
+			password:     "hashcat",
+		},
+		"non-canonical sha256crypt": {
+			inner:        &sha256crypt.Function{},
+			outer:        &sha512crypt.Function{},
+			innerEncoded: `$5$rounds=5000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD`,
+			password:     "hashcat",
+		},
+		"mariaDBOldPassword": {
+			inner:        &mariadboldpassword.Function{},
+			outer:        &sha256crypt.Function{},
+			innerEncoded: `7196759210defdc0`,
+			password:     "hashcat",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			f := wrapped.New(tc.inner, tc.outer)
+			encodedHash, err := f.Wrap(context.Background(),
+				[]byte(tc.innerEncoded), nil, 0)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			match, err := pwhash.Verify(context.Background(), f,
+				[]byte(encodedHash), []byte(tc.password), nil)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if !match {
+				tt.Fatalf("password doesn't match wrapped hash %s", encodedHash)
+			}
+		})
+	}
+}
+
+func TestVerifyInnerCost(t *testing.T) {
+	f := wrapped.New(&sha256crypt.Function{}, &sha512crypt.Function{})
+	// Wrap doesn't limit the inner cost of trusted hashes
+	encodedHash, err := f.Wrap(context.Background(), []byte(
+		`$5$rounds=100000$GX7BopJZJxPc/KEK$B0.otSf8vrLEEGD/pM4aaZMj/gpn3R9yyoAXvNAFQY/`),
+		nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testCases = map[string]struct {
+		opts      *pwhash.VerifyOptions
+		expectErr error
+	}{
+		"default max cost": {
+			expectErr: pwhash.ErrCostLimit,
+		},
+		"max cost": {
+			opts: &pwhash.VerifyOptions{
+				MaxCost: map[string]uint{sha256crypt.ID: 100000},
+			},
+		},
+		"max cost exceeded": {
+			opts: &pwhash.VerifyOptions{
+				MaxCost:          map[string]uint{sha256crypt.ID: 99999},
+				NoDefaultMaxCost: true,
+			},
+			expectErr: pwhash.ErrCostLimit,
+		},
+		"no default max cost": {
+			opts: &pwhash.VerifyOptions{NoDefaultMaxCost: true},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			match, err := pwhash.Verify(context.Background(), f,
+				[]byte(encodedHash), []byte("hashcat"), tc.opts)
+			if !errors.Is(err, tc.expectErr) {
+				tt.Fatalf("expected err %v, got %v", tc.expectErr, err)
+			}
+			if match != (tc.expectErr == nil) {
+				tt.Fatalf("expected match %v, got %v", tc.expectErr == nil, match)
+			}
+		})
+	}
+}
+
+func TestValidateSalt(t *testing.T) {
+	f := wrapped.New(&md5crypt.Function{}, &sha512crypt.Function{})
+	var testCases = map[string]struct {
+		input  string
+		expect error
+	}{
+		"valid":         {input: "0$28772684$zrr5Kt7jpmLAHTeX", expect: nil},
+		"missing parts": {input: "zrr5Kt7jpmLAHTeX", expect: pwhash.ErrSaltCharset},
+		"invalid cost":  {input: "x$28772684$zrr5Kt7jpmLAHTeX", expect: pwhash.ErrSaltCharset},
+		"invalid inner": {input: "0$287726841$zrr5Kt7jpmLAHTeX", expect: pwhash.ErrSaltLen},
+		"invalid outer": {input: "0$28772684$", expect: pwhash.ErrSaltLen},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			err := f.ValidateSalt([]byte(tc.input))
+			if !errors.Is(err, tc.expect) {
+				tt.Fatalf("expected err %v, got %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func TestGenerateSalt(t *testing.T) {
+	f := wrapped.New(&sha256crypt.Function{}, &sha512crypt.Function{})
+	salt, err := f.GenerateSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.ValidateSalt(salt); err != nil {
+		t.Fatalf("generated salt %s is invalid: %v", salt, err)
+	}
+}
@@ -0,0 +1,89 @@
+package strength
+
+// keyboard is the layout of a keyboard with slanted rows, in which each row
+// is offset by half a key from the row above. The neighbours of the key at
+// row r, column c are at (r, c±1), (r-1, c), (r-1, c+1), (r+1, c-1) and
+// (r+1, c).
+type keyboard struct {
+	// keys maps each character to its position.
+	keys map[rune][2]int
+	// shifted is the set of characters typed with shift.
+	shifted map[rune]bool
+	// layout maps each position to its characters.
+	layout map[[2]int][]rune
+}
+
+// neighbourOffsets are the row and column offsets of the neighbours of a key,
+// which define the direction between adjacent keys.
+var neighbourOffsets = [][2]int{{0, -1}, {0, 1}, {-1, 0}, {-1, 1}, {1, -1},
+	{1, 0}}
+
+// newKeyboard returns a keyboard with the given rows of unshifted and shifted
+// characters, each starting at the given column.
+func newKeyboard(rows []string, shiftedRows []string,
+	startCols []int) *keyboard {
+	k := keyboard{
+		keys:    map[rune][2]int{},
+		shifted: map[rune]bool{},
+		layout:  map[[2]int][]rune{},
+	}
+	for r := range rows {
+		shiftedRow := []rune(shiftedRows[r])
+		for c, char := range []rune(rows[r]) {
+			pos := [2]int{r, startCols[r] + c}
+			k.keys[char] = pos
+			k.keys[shiftedRow[c]] = pos
+			k.shifted[shiftedRow[c]] = true
+			k.layout[pos] = []rune{char, shiftedRow[c]}
+		}
+	}
+	return &k
+}
+
+// qwerty is the US qwerty keyboard layout.
+var qwerty = newKeyboard(
+	[]string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"},
+	[]string{"~!@#$%^&*()_+", "QWERTYUIOP{}|", "ASDFGHJKL:\"", "ZXCVBNM<>?"},
+	[]int{0, 1, 1, 1})
+
+// direction returns the index in neighbourOffsets of the direction from key a
+// to key b, or -1 if the keys are not adjacent.
+func (k *keyboard) direction(a, b rune) int {
+	pa, ok := k.keys[a]
+	if !ok {
+		return -1
+	}
+	pb, ok := k.keys[b]
+	if !ok {
+		return -1
+	}
+	for dir, o := range neighbourOffsets {
+		if pa[0]+o[0] == pb[0] && pa[1]+o[1] == pb[1] {
+			return dir
+		}
+	}
+	return -1
+}
+
+// isShifted returns true if r is typed with shift.
+func (k *keyboard) isShifted(r rune) bool {
+	return k.shifted[r]
+}
+
+// startingPositions returns the number of characters on the keyboard.
+func (k *keyboard) startingPositions() float64 {
+	return float64(len(k.keys))
+}
+
+// averageDegree returns the average number of neighbours of a key.
+func (k *keyboard) averageDegree() float64 {
+	var degree int
+	for pos := range k.layout {
+		for _, o := range neighbourOffsets {
+			if _, ok := k.layout[[2]int{pos[0] + o[0], pos[1] + o[1]}]; ok {
+				degree++
+			}
+		}
+	}
+	return float64(degree) / float64(len(k.layout))
+}
@@ -0,0 +1,500 @@
+package strength
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Pattern is a kind of easily guessed pattern in a password.
+type Pattern int
+
+// Patterns.
+const (
+	// Bruteforce matches any characters which don't match another pattern.
+	Bruteforce Pattern = iota
+	// Dictionary matches a word in a dictionary, possibly reversed or with l33t
+	// substitutions.
+	Dictionary
+	// Sequence matches characters with evenly spaced codepoints, such as abc
+	// or 6543.
+	Sequence
+	// Repeat matches a repeated string, such as aaa or abcabc.
+	Repeat
+	// Spatial matches adjacent keys on a keyboard, such as qwerty.
+	Spatial
+	// Date matches a date or year.
+	Date
+)
+
+// String implements fmt.Stringer.
+func (p Pattern) String() string {
+	switch p {
+	case Bruteforce:
+		return "bruteforce"
+	case Dictionary:
+		return "dictionary"
+	case Sequence:
+		return "sequence"
+	case Repeat:
+		return "repeat"
+	case Spatial:
+		return "spatial"
+	case Date:
+		return "date"
+	default:
+		return "unknown"
+	}
+}
+
+// dictionary names.
+const (
+	passwordsDict  = "passwords"
+	userInputsDict = "user_inputs"
+)
+
+// Match is a part of a password which matches a pattern.
+type Match struct {
+	// Pattern is the kind of pattern matched.
+	Pattern Pattern
+	// Token is the matched part of the password.
+	Token string
+	// Guesses is the estimated number of guesses needed to find Token.
+	Guesses float64
+	// Dictionary is the name of the dictionary containing the matched word of
+	// a Dictionary match.
+	Dictionary string
+	// Rank is the rank of the matched word of a Dictionary match, starting at
+	// 1 for the most common word.
+	Rank int
+	// Reversed is true if a Dictionary match is a reversed word.
+	Reversed bool
+	// L33t is true if a Dictionary match contains l33t substitutions, such as
+	// p4ssw0rd.
+	L33t bool
+
+	// i and j are the indices of the first and last rune of Token.
+	i, j int
+	// sub maps the l33t characters of a Dictionary match to letters.
+	sub map[rune]rune
+	// ascending is true for a Sequence match of increasing codepoints.
+	ascending bool
+	// base is the number of guesses needed to find the repeated string of a
+	// Repeat match, and count is the number of repetitions.
+	base  float64
+	count int
+	// turns and shifted are the number of changes of direction and shifted
+	// keys of a Spatial match.
+	turns, shifted int
+	// year of a Date match, and separator is true if the date contains
+	// separators.
+	year      int
+	separator bool
+}
+
+// omnimatch returns all the matches in the given password, sorted by
+// position.
+func omnimatch(password []rune,
+	dicts map[string]map[string]int) []Match {
+	var matches []Match
+	matches = append(matches, dictionaryMatch(password, dicts)...)
+	matches = append(matches, reverseDictionaryMatch(password, dicts)...)
+	matches = append(matches, l33tMatch(password, dicts)...)
+	matches = append(matches, sequenceMatch(password)...)
+	matches = append(matches, repeatMatch(password, dicts)...)
+	matches = append(matches, spatialMatch(password)...)
+	matches = append(matches, dateMatch(password)...)
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].i != matches[b].i {
+			return matches[a].i < matches[b].i
+		}
+		return matches[a].j < matches[b].j
+	})
+	return matches
+}
+
+// dictionaryMatch returns the substrings of the password which are words in
+// the given dictionaries, ignoring case.
+func dictionaryMatch(password []rune,
+	dicts map[string]map[string]int) []Match {
+	lower := []rune(strings.ToLower(string(password)))
+	var names []string
+	for name := range dicts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var matches []Match
+	for _, name := range names {
+		dict := dicts[name]
+		for i := range password {
+			for j := i; j < len(password); j++ {
+				if rank, ok := dict[string(lower[i:j+1])]; ok {
+					matches = append(matches, Match{
+						Pattern:    Dictionary,
+						Token:      string(password[i : j+1]),
+						Dictionary: name,
+						Rank:       rank,
+						i:          i,
+						j:          j,
+					})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// reverse returns the given runes in reverse order.
+func reverse(r []rune) []rune {
+	reversed := make([]rune, len(r))
+	for i := range r {
+		reversed[len(r)-1-i] = r[i]
+	}
+	return reversed
+}
+
+// reverseDictionaryMatch returns the substrings of the password which are
+// reversed words in the given dictionaries.
+func reverseDictionaryMatch(password []rune,
+	dicts map[string]map[string]int) []Match {
+	reversed := reverse(password)
+	var matches []Match
+	for _, m := range dictionaryMatch(reversed, dicts) {
+		// ignore palindromes, which are already matched
+		if m.Token == string(reverse([]rune(m.Token))) {
+			continue
+		}
+		m.Token = string(reverse([]rune(m.Token)))
+		m.i, m.j = len(password)-1-m.j, len(password)-1-m.i
+		m.Reversed = true
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// l33tTable maps l33t characters to the letters they may substitute.
+var l33tTable = map[rune][]rune{
+	'4': {'a'}, '@': {'a'},
+	'8': {'b'},
+	'(': {'c'}, '{': {'c'}, '[': {'c'}, '<': {'c'},
+	'3': {'e'},
+	'6': {'g'}, '9': {'g'},
+	'1': {'i', 'l'}, '!': {'i'}, '|': {'i', 'l'},
+	'7': {'l', 't'},
+	'0': {'o'},
+	'$': {'s'}, '5': {'s'},
+	'+': {'t'},
+	'%': {'x'},
+	'2': {'z'},
+}
+
+// maxL33tSubs limits the number of substitution tables tried for a password.
+const maxL33tSubs = 64
+
+// l33tSubs returns the possible substitution tables for the l33t characters
+// in the given password.
+func l33tSubs(password []rune) []map[rune]rune {
+	subs := []map[rune]rune{{}}
+	seen := map[rune]bool{}
+	for _, r := range password {
+		letters, ok := l33tTable[r]
+		if !ok || seen[r] {
+			continue
+		}
+		seen[r] = true
+		var next []map[rune]rune
+		for _, sub := range subs {
+			for _, l := range letters {
+				extended := map[rune]rune{r: l}
+				for k, v := range sub {
+					extended[k] = v
+				}
+				next = append(next, extended)
+			}
+		}
+		if len(next) > maxL33tSubs {
+			next = next[:maxL33tSubs]
+		}
+		subs = next
+	}
+	if len(subs[0]) == 0 {
+		return nil
+	}
+	return subs
+}
+
+// l33tMatch returns the substrings of the password which are words in the
+// given dictionaries after l33t substitutions.
+func l33tMatch(password []rune,
+	dicts map[string]map[string]int) []Match {
+	var matches []Match
+	seen := map[[2]int]bool{}
+	for _, sub := range l33tSubs(password) {
+		subbed := make([]rune, len(password))
+		for i, r := range password {
+			if l, ok := sub[r]; ok {
+				subbed[i] = l
+			} else {
+				subbed[i] = r
+			}
+		}
+		for _, m := range dictionaryMatch(subbed, dicts) {
+			token := password[m.i : m.j+1]
+			// only keep the substitutions used in the token
+			used := map[rune]rune{}
+			for _, r := range token {
+				if l, ok := sub[r]; ok {
+					used[r] = l
+				}
+			}
+			// single characters and tokens without substitutions are not l33t
+			if len(used) == 0 || len(token) < 2 || seen[[2]int{m.i, m.j}] {
+				continue
+			}
+			seen[[2]int{m.i, m.j}] = true
+			m.Token = string(token)
+			m.L33t = true
+			m.sub = used
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// maxDelta is the maximum difference between codepoints in a sequence.
+const maxDelta = 5
+
+// sequenceMatch returns the runs of at least three characters with evenly
+// spaced codepoints in the password.
+func sequenceMatch(password []rune) []Match {
+	var matches []Match
+	emit := func(i, j, delta int) {
+		if j-i < 2 || delta == 0 || delta > maxDelta || delta < -maxDelta {
+			return
+		}
+		matches = append(matches, Match{
+			Pattern:   Sequence,
+			Token:     string(password[i : j+1]),
+			i:         i,
+			j:         j,
+			ascending: delta > 0,
+		})
+	}
+	i, lastDelta := 0, 0
+	for k := 1; k < len(password); k++ {
+		delta := int(password[k] - password[k-1])
+		if k == i+1 {
+			lastDelta = delta
+			continue
+		}
+		if delta != lastDelta {
+			emit(i, k-1, lastDelta)
+			i, lastDelta = k-1, delta
+		}
+	}
+	emit(i, len(password)-1, lastDelta)
+	return matches
+}
+
+// repeatMatch returns the repeated strings in the password.
+func repeatMatch(password []rune,
+	dicts map[string]map[string]int) []Match {
+	var matches []Match
+	for i := 0; i < len(password); {
+		// find the repeated unit with the longest coverage
+		bestUnit, bestCount := 0, 0
+		for u := 1; i+2*u <= len(password); u++ {
+			count := 1
+			for i+(count+1)*u <= len(password) &&
+				string(password[i+count*u:i+(count+1)*u]) == string(password[i:i+u]) {
+				count++
+			}
+			if count > 1 && u*count > bestUnit*bestCount {
+				bestUnit, bestCount = u, count
+			}
+		}
+		if bestCount < 2 {
+			i++
+			continue
+		}
+		unit := password[i : i+bestUnit]
+		base, _ := mostGuessable(unit, omnimatch(unit, dicts))
+		j := i + bestUnit*bestCount - 1
+		matches = append(matches, Match{
+			Pattern: Repeat,
+			Token:   string(password[i : j+1]),
+			i:       i,
+			j:       j,
+			base:    base,
+			count:   bestCount,
+		})
+		i = j + 1
+	}
+	return matches
+}
+
+// spatialMatch returns the runs of at least three adjacent keys on a qwerty
+// keyboard in the password.
+func spatialMatch(password []rune) []Match {
+	var matches []Match
+	for i := 0; i < len(password)-2; {
+		j, turns, lastDir := i, 0, -1
+		shifted := 0
+		if qwerty.isShifted(password[i]) {
+			shifted++
+		}
+		for j+1 < len(password) {
+			dir := qwerty.direction(password[j], password[j+1])
+			if dir < 0 {
+				break
+			}
+			if dir != lastDir {
+				turns++
+				lastDir = dir
+			}
+			if qwerty.isShifted(password[j+1]) {
+				shifted++
+			}
+			j++
+		}
+		if j-i < 2 {
+			i++
+			continue
+		}
+		matches = append(matches, Match{
+			Pattern: Spatial,
+			Token:   string(password[i : j+1]),
+			i:       i,
+			j:       j,
+			turns:   turns,
+			shifted: shifted,
+		})
+		i = j + 1
+	}
+	return matches
+}
+
+// referenceYear is the year used to estimate the guesses needed for dates.
+var referenceYear = time.Now().Year()
+
+// isDigits returns true if the given runes are all ASCII digits.
+func isDigits(r []rune) bool {
+	for _, c := range r {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return len(r) > 0
+}
+
+// isSeparator returns true if r is a common date separator.
+func isSeparator(r rune) bool {
+	return r == '/' || r == '\\' || r == '_' || r == '.' || r == '-' ||
+		unicode.IsSpace(r)
+}
+
+// validDate returns the year of the given day, month and year, expanding two
+// digit years, and false if the date is not valid.
+func validDate(day, month, year, yearLen int) (int, bool) {
+	if day < 1 || day > 31 || month < 1 || month > 12 {
+		return 0, false
+	}
+	switch {
+	case yearLen <= 2 && year > 50:
+		year += 1900
+	case yearLen <= 2:
+		year += 2000
+	case year < 1000 || year > 2050:
+		return 0, false
+	}
+	return year, true
+}
+
+// parseDate returns the year of the date formed by the given digit groups in
+// year-month-day, day-month-year or month-day-year order.
+func parseDate(groups [3][]rune) (int, bool) {
+	n := [3]int{}
+	for k, g := range groups {
+		n[k], _ = strconv.Atoi(string(g))
+	}
+	if year, ok := validDate(n[2], n[1], n[0], len(groups[0])); ok &&
+		len(groups[0]) == 4 {
+		return year, true
+	}
+	if len(groups[0]) > 2 || len(groups[1]) > 2 {
+		return 0, false
+	}
+	if year, ok := validDate(n[0], n[1], n[2], len(groups[2])); ok {
+		return year, true
+	}
+	return validDate(n[1], n[0], n[2], len(groups[2]))
+}
+
+// dateMatch returns the years between 1900 and 2099, and the dates with or
+// without separators, in the password.
+func dateMatch(password []rune) []Match {
+	var matches []Match
+	for i := range password {
+		for j := i + 3; j < len(password) && j < i+10; j++ {
+			token := password[i : j+1]
+			var year int
+			var ok, separator bool
+			switch {
+			case len(token) == 4 && isDigits(token):
+				year, _ = strconv.Atoi(string(token))
+				ok = year >= 1900 && year <= 2099
+			case len(token) == 6 && isDigits(token):
+				year, ok = parseDate([3][]rune{token[:2], token[2:4], token[4:]})
+			case len(token) == 8 && isDigits(token):
+				year, ok = parseDate([3][]rune{token[:4], token[4:6], token[6:]})
+				if !ok {
+					year, ok = parseDate([3][]rune{token[:2], token[2:4], token[4:]})
+				}
+			default:
+				year, ok = separatedDate(token)
+				separator = ok
+			}
+			if ok {
+				matches = append(matches, Match{
+					Pattern:   Date,
+					Token:     string(token),
+					i:         i,
+					j:         j,
+					year:      year,
+					separator: separator,
+				})
+			}
+		}
+	}
+	return matches
+}
+
+// separatedDate returns the year of the given date with separators, such as
+// 1990-04-19 or 19/4/90.
+func separatedDate(token []rune) (int, bool) {
+	var groups [][]rune
+	var seps []rune
+	start := 0
+	for k, r := range token {
+		if isSeparator(r) {
+			groups = append(groups, token[start:k])
+			seps = append(seps, r)
+			start = k + 1
+		}
+	}
+	groups = append(groups, token[start:])
+	if len(groups) != 3 || seps[0] != seps[1] {
+		return 0, false
+	}
+	for _, g := range groups {
+		if !isDigits(g) || len(g) > 4 {
+			return 0, false
+		}
+	}
+	if len(groups[1]) > 2 {
+		return 0, false
+	}
+	return parseDate([3][]rune{groups[0], groups[1], groups[2]})
+}
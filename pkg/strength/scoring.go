@@ -0,0 +1,288 @@
+package strength
+
+import (
+	"math"
+	"sort"
+	"unicode"
+)
+
+const (
+	// bruteforceCardinality is the assumed number of guesses per character
+	// of a brute force match.
+	bruteforceCardinality = 10
+	// minGuessesSingleChar and minGuessesMultiChar are the minimum guesses for
+	// a match which doesn't cover the whole password, so that patterns don't
+	// make a password look weaker than brute force would.
+	minGuessesSingleChar = 10
+	minGuessesMultiChar  = 50
+	// minGuessesBeforeGrowingSequence penalises sequences of many matches.
+	minGuessesBeforeGrowingSequence = 10000
+	// minYearSpace is the minimum number of years guessed for a date.
+	minYearSpace = 20
+)
+
+// nCk returns the binomial coefficient n choose k.
+func nCk(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	r := 1.0
+	for d := 1; d <= k; d++ {
+		r *= float64(n)
+		r /= float64(d)
+		n--
+	}
+	return r
+}
+
+// factorial returns n!.
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
+
+// variations returns the number of ways to choose which of a and b
+// characters are which, given that a is the less common variant: 1 if there
+// are no a characters, 2 if there are no b characters, and the sum of
+// choosing up to min(a, b) otherwise.
+func variations(a, b int) float64 {
+	if a == 0 {
+		return 1
+	}
+	if b == 0 {
+		return 2
+	}
+	var v float64
+	for i := 1; i <= a && i <= b; i++ {
+		v += nCk(a+b, i)
+	}
+	return v
+}
+
+// uppercaseVariations returns the number of guesses needed to find the
+// capitalisation of the given token.
+func uppercaseVariations(token []rune) float64 {
+	var upper, lower int
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	// capitalised first or last letter, or all upper case, are common
+	first, last := token[0], token[len(token)-1]
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(first) ||
+		unicode.IsUpper(last))) {
+		return 2
+	}
+	if upper < lower {
+		return variations(upper, lower)
+	}
+	return variations(lower, upper)
+}
+
+// l33tVariations returns the number of guesses needed to find the l33t
+// substitutions of the given match.
+func l33tVariations(m *Match) float64 {
+	if !m.L33t {
+		return 1
+	}
+	v := 1.0
+	lower := []rune(m.Token)
+	for i := range lower {
+		lower[i] = unicode.ToLower(lower[i])
+	}
+	for l33t, letter := range m.sub {
+		var subbed, unsubbed int
+		for _, r := range lower {
+			switch r {
+			case l33t:
+				subbed++
+			case letter:
+				unsubbed++
+			}
+		}
+		if unsubbed == 0 {
+			v *= 2
+		} else {
+			v *= variations(subbed, unsubbed)
+		}
+	}
+	return v
+}
+
+// spatialGuesses returns the number of guesses needed to find a keyboard
+// pattern of the given length, turns and shifted keys.
+func spatialGuesses(length, turns, shifted int) float64 {
+	s := qwerty.startingPositions()
+	d := qwerty.averageDegree()
+	var g float64
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= turns && j <= i-1; j++ {
+			g += nCk(i-1, j-1) * s * math.Pow(d, float64(j))
+		}
+	}
+	if shifted > 0 {
+		if unshifted := length - shifted; unshifted < shifted {
+			g *= variations(unshifted, shifted)
+		} else {
+			g *= variations(shifted, unshifted)
+		}
+	}
+	return g
+}
+
+// estimateGuesses sets and returns the number of guesses needed to find the
+// given match in a password of the given length.
+func estimateGuesses(m *Match, passwordLen int) float64 {
+	token := []rune(m.Token)
+	var g float64
+	switch m.Pattern {
+	case Bruteforce:
+		g = math.Pow(bruteforceCardinality, float64(len(token)))
+		// add one so that brute force is never preferred over an equal match
+		if len(token) == 1 {
+			g = math.Max(g, minGuessesSingleChar+1)
+		} else {
+			g = math.Max(g, minGuessesMultiChar+1)
+		}
+	case Dictionary:
+		g = float64(m.Rank) * uppercaseVariations(token) * l33tVariations(m)
+		if m.Reversed {
+			g *= 2
+		}
+	case Sequence:
+		var base float64
+		switch first := token[0]; {
+		case first == 'a' || first == 'A' || first == 'z' || first == 'Z' ||
+			first == '0' || first == '1' || first == '9':
+			base = 4
+		case '0' <= first && first <= '9':
+			base = 10
+		default:
+			base = 26
+		}
+		if !m.ascending {
+			base *= 2
+		}
+		g = base * float64(len(token))
+	case Repeat:
+		g = m.base * float64(m.count)
+	case Spatial:
+		g = spatialGuesses(len(token), m.turns, m.shifted)
+	case Date:
+		years := math.Max(math.Abs(float64(m.year-referenceYear)), minYearSpace)
+		g = years
+		if len(token) > 4 {
+			g *= 365
+		}
+		if m.separator {
+			g *= 4
+		}
+	}
+	if m.Pattern != Bruteforce && len(token) < passwordLen {
+		if len(token) == 1 {
+			g = math.Max(g, minGuessesSingleChar)
+		} else {
+			g = math.Max(g, minGuessesMultiChar)
+		}
+	}
+	m.Guesses = g
+	return g
+}
+
+// mostGuessable returns the number of guesses needed to find the password,
+// and the sequence of non-overlapping matches covering the password which
+// needs the fewest guesses, as per zxcvbn. The matches must be sorted by
+// position. Gaps between matches are filled with brute force matches.
+func mostGuessable(password []rune, matches []Match) (float64, []Match) {
+	n := len(password)
+	if n == 0 {
+		return 1, nil
+	}
+	// optimal[k][l] is the best sequence of l matches ending at rune k
+	type candidate struct {
+		m  Match
+		pi float64 // product of guesses of the sequence
+		g  float64 // overall guesses of the sequence
+	}
+	lengths := func(c map[int]candidate) []int {
+		var ls []int
+		for l := range c {
+			ls = append(ls, l)
+		}
+		sort.Ints(ls)
+		return ls
+	}
+	optimal := make([]map[int]candidate, n)
+	for k := range optimal {
+		optimal[k] = map[int]candidate{}
+	}
+	update := func(m Match, l int) {
+		k := m.j
+		pi := estimateGuesses(&m, n)
+		if l > 1 {
+			pi *= optimal[m.i-1][l-1].pi
+		}
+		g := factorial(l)*pi +
+			math.Pow(minGuessesBeforeGrowingSequence, float64(l-1))
+		// skip if a sequence of no more matches is at least as good
+		for cl, c := range optimal[k] {
+			if cl <= l && c.g <= g {
+				return
+			}
+		}
+		optimal[k][l] = candidate{m: m, pi: pi, g: g}
+	}
+	bruteforce := func(i, j int) Match {
+		return Match{Pattern: Bruteforce, Token: string(password[i : j+1]),
+			i: i, j: j}
+	}
+	for k := 0; k < n; k++ {
+		for _, m := range matches {
+			if m.j != k {
+				continue
+			}
+			if m.i == 0 {
+				update(m, 1)
+				continue
+			}
+			for _, l := range lengths(optimal[m.i-1]) {
+				update(m, l+1)
+			}
+		}
+		update(bruteforce(0, k), 1)
+		for i := 1; i <= k; i++ {
+			for _, l := range lengths(optimal[i-1]) {
+				// adjacent brute force matches are never optimal
+				if optimal[i-1][l].m.Pattern == Bruteforce {
+					continue
+				}
+				update(bruteforce(i, k), l+1)
+			}
+		}
+	}
+	// find the best sequence ending at the last rune
+	bestL, bestG := 0, math.Inf(1)
+	for _, l := range lengths(optimal[n-1]) {
+		if c := optimal[n-1][l]; c.g < bestG {
+			bestL, bestG = l, c.g
+		}
+	}
+	// unwind the sequence
+	sequence := make([]Match, bestL)
+	for k, l := n-1, bestL; l > 0; l-- {
+		c := optimal[k][l]
+		sequence[l-1] = c.m
+		k = c.m.i - 1
+	}
+	return bestG, sequence
+}
@@ -0,0 +1,178 @@
+// Package strength implements estimation of password strength in the style of
+// zxcvbn (https://github.com/dropbox/zxcvbn).
+//
+// A password is split into the sequence of patterns which is easiest to
+// guess: common passwords (including reversed and l33t variants), sequences
+// such as "abc", repeats such as "aaa", keyboard patterns such as "qwerty",
+// dates and years. Parts of the password which don't match a pattern are
+// assumed to be guessed by brute force. The estimated number of guesses
+// needed to find the password is mapped to a Score.
+package strength
+
+import (
+	_ "embed"
+	"strings"
+)
+
+// maxLen is the number of runes of a password which are analysed. Longer
+// passwords are truncated, which limits the cost of the analysis and only
+// underestimates their strength.
+const maxLen = 64
+
+// Score is a coarse measure of password strength.
+type Score int
+
+// Scores, as per zxcvbn.
+const (
+	// VeryWeak passwords are guessable with fewer than 10^3 guesses.
+	VeryWeak Score = iota
+	// Weak passwords are guessable with fewer than 10^6 guesses.
+	Weak
+	// Fair passwords are guessable with fewer than 10^8 guesses.
+	Fair
+	// Strong passwords are guessable with fewer than 10^10 guesses.
+	Strong
+	// VeryStrong passwords need at least 10^10 guesses.
+	VeryStrong
+)
+
+// String implements fmt.Stringer.
+func (s Score) String() string {
+	switch s {
+	case VeryWeak:
+		return "very weak"
+	case Weak:
+		return "weak"
+	case Fair:
+		return "fair"
+	case Strong:
+		return "strong"
+	case VeryStrong:
+		return "very strong"
+	default:
+		return "unknown"
+	}
+}
+
+// score returns the Score for the given number of guesses.
+func score(guesses float64) Score {
+	switch {
+	case guesses < 1e3:
+		return VeryWeak
+	case guesses < 1e6:
+		return Weak
+	case guesses < 1e8:
+		return Fair
+	case guesses < 1e10:
+		return Strong
+	default:
+		return VeryStrong
+	}
+}
+
+// Result is the estimated strength of a password.
+type Result struct {
+	// Guesses is the estimated number of guesses needed to find the password.
+	Guesses float64
+	// Score is the coarse strength of the password.
+	Score Score
+	// Sequence is the sequence of matches which is easiest to guess, covering
+	// the analysed part of the password.
+	Sequence []Match
+	// Warning explains why the password is weak, or is empty if the password
+	// is not weak or there is no specific reason.
+	Warning string
+}
+
+//go:embed passwords.txt
+var passwordsList string
+
+// passwords maps common passwords to their rank, starting at 1.
+var passwords = rankedDictionary(strings.Split(passwordsList, "\n"))
+
+// rankedDictionary returns a map of the given words to their rank, ignoring
+// empty lines and comments.
+func rankedDictionary(words []string) map[string]int {
+	ranked := map[string]int{}
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" || strings.HasPrefix(w, "#") {
+			continue
+		}
+		if _, ok := ranked[w]; !ok {
+			ranked[w] = len(ranked) + 1
+		}
+	}
+	return ranked
+}
+
+// IsCommon returns true if the given password is in the embedded list of
+// common passwords, ignoring case.
+func IsCommon(password string) bool {
+	_, ok := passwords[strings.ToLower(password)]
+	return ok
+}
+
+// Estimate returns the estimated strength of the given password. The
+// userInputs are words which are easy to guess for this particular password,
+// such as the username or email address of the user.
+func Estimate(password string, userInputs ...string) *Result {
+	dicts := map[string]map[string]int{
+		passwordsDict:  passwords,
+		userInputsDict: rankedDictionary(userInputs),
+	}
+	runes := []rune(password)
+	if len(runes) > maxLen {
+		runes = runes[:maxLen]
+	}
+	guesses, sequence := mostGuessable(runes, omnimatch(runes, dicts))
+	r := Result{
+		Guesses:  guesses,
+		Score:    score(guesses),
+		Sequence: sequence,
+	}
+	if r.Score <= Fair {
+		r.Warning = warning(sequence)
+	}
+	return &r
+}
+
+// warning returns an explanation of the weakness of the longest match in the
+// given sequence.
+func warning(sequence []Match) string {
+	var longest *Match
+	for i := range sequence {
+		if longest == nil ||
+			len([]rune(sequence[i].Token)) > len([]rune(longest.Token)) {
+			longest = &sequence[i]
+		}
+	}
+	if longest == nil {
+		return ""
+	}
+	switch longest.Pattern {
+	case Dictionary:
+		switch {
+		case longest.Dictionary == userInputsDict:
+			return "This contains personal information"
+		case longest.L33t || longest.Reversed:
+			return "This is similar to a commonly used password"
+		case longest.Rank <= 10 && len(sequence) == 1:
+			return "This is a top-10 common password"
+		case longest.Rank <= 100 && len(sequence) == 1:
+			return "This is a top-100 common password"
+		default:
+			return "This contains a very common password"
+		}
+	case Sequence:
+		return "Sequences like abc or 6543 are easy to guess"
+	case Repeat:
+		return "Repeats like \"aaa\" or \"abcabc\" are easy to guess"
+	case Spatial:
+		return "Keyboard patterns like qwerty are easy to guess"
+	case Date:
+		return "Dates and years are easy to guess"
+	default:
+		return ""
+	}
+}
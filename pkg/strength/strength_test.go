@@ -0,0 +1,123 @@
+package strength_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/smlx/hashy/pkg/strength"
+)
+
+func TestEstimate(t *testing.T) {
+	var testCases = map[string]struct {
+		password      string
+		userInputs    []string
+		expectScore   strength.Score
+		expectPattern strength.Pattern
+	}{
+		"empty": {
+			password:    "",
+			expectScore: strength.VeryWeak,
+		},
+		"common": {
+			password:      "password",
+			expectScore:   strength.VeryWeak,
+			expectPattern: strength.Dictionary,
+		},
+		"common capitalised": {
+			password:      "Password",
+			expectScore:   strength.VeryWeak,
+			expectPattern: strength.Dictionary,
+		},
+		"l33t": {
+			password:      "p@ssw0rd",
+			expectScore:   strength.VeryWeak,
+			expectPattern: strength.Dictionary,
+		},
+		"reversed": {
+			password:      "drowssap",
+			expectScore:   strength.VeryWeak,
+			expectPattern: strength.Dictionary,
+		},
+		"sequence": {
+			password:      "lmnopqrs",
+			expectScore:   strength.VeryWeak,
+			expectPattern: strength.Sequence,
+		},
+		"repeat": {
+			password:      "xyxyxyxyxy",
+			expectScore:   strength.VeryWeak,
+			expectPattern: strength.Repeat,
+		},
+		"spatial": {
+			password:      "ghjkl;'",
+			expectScore:   strength.Weak,
+			expectPattern: strength.Spatial,
+		},
+		"date": {
+			password:      "19/04/1990",
+			expectScore:   strength.Weak,
+			expectPattern: strength.Date,
+		},
+		"user input": {
+			password:      "Zaphod",
+			userInputs:    []string{"zaphod@example.com", "zaphod"},
+			expectScore:   strength.VeryWeak,
+			expectPattern: strength.Dictionary,
+		},
+		"random": {
+			password:      "x9#Lm2$qVz!8Tp",
+			expectScore:   strength.VeryStrong,
+			expectPattern: strength.Bruteforce,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(tt *testing.T) {
+			r := strength.Estimate(tc.password, tc.userInputs...)
+			if r.Score != tc.expectScore {
+				tt.Fatalf("expected score %v, got %v (%v guesses)", tc.expectScore,
+					r.Score, r.Guesses)
+			}
+			if tc.password == "" {
+				return
+			}
+			if len(r.Sequence) != 1 {
+				tt.Fatalf("expected 1 match, got %+v", r.Sequence)
+			}
+			if p := r.Sequence[0].Pattern; p != tc.expectPattern {
+				tt.Fatalf("expected pattern %v, got %v", tc.expectPattern, p)
+			}
+			if r.Score <= strength.Fair && r.Warning == "" &&
+				tc.expectPattern != strength.Bruteforce {
+				tt.Fatalf("expected warning")
+			}
+		})
+	}
+}
+
+func TestEstimateSequence(t *testing.T) {
+	r := strength.Estimate("monkey1990qwerty")
+	var tokens []string
+	for _, m := range r.Sequence {
+		tokens = append(tokens, m.Token)
+	}
+	if got := strings.Join(tokens, " "); got != "monkey 1990 qwerty" {
+		t.Fatalf("expected sequence \"monkey 1990 qwerty\", got %q", got)
+	}
+}
+
+func TestEstimateLong(t *testing.T) {
+	// long passwords are truncated rather than analysed in full
+	r := strength.Estimate(strings.Repeat("x9#Lm2$qVz!8Tp", 1000))
+	if r.Score != strength.VeryStrong {
+		t.Fatalf("expected score %v, got %v", strength.VeryStrong, r.Score)
+	}
+}
+
+func TestIsCommon(t *testing.T) {
+	if !strength.IsCommon("Password") {
+		t.Fatal("expected Password to be common")
+	}
+	if strength.IsCommon("x9#Lm2$qVz!8Tp") {
+		t.Fatal("expected x9#Lm2$qVz!8Tp not to be common")
+	}
+}